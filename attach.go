@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/console"
+	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
+)
+
+const defaultDetachKeys = "ctrl-p,ctrl-q"
+
+var attachCommand = cli.Command{
+	Name:  "attach",
+	Usage: "attach to the terminal or stdio of a running container",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is the name for the instance of the container.`,
+	Description: `The attach command connects runc's stdin, stdout and stderr to the terminal
+(or stdio) of a container created with the --attachable option.
+
+Only one client at a time can write to the container, but any number of
+read-only clients can be attached. To detach, type the detach key sequence
+(` + defaultDetachKeys + ` by default). Once the container exits, runc exits
+with the container's exit status.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "read-only, r",
+			Usage: "only print the container output, do not forward stdin",
+		},
+		cli.StringFlag{
+			Name:  "detach-keys",
+			Value: defaultDetachKeys,
+			Usage: "key sequence to detach from the container (comma-separated list of characters or ctrl-<value>)",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		detachKeys, err := parseDetachKeys(context.String("detach-keys"))
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(context.GlobalString("root"), container.ID(), attachSocketFilename)
+		status, err := attach(path, context.Bool("read-only"), detachKeys)
+		if err != nil {
+			return err
+		}
+		os.Exit(status)
+		return nil // to satisfy the linter
+	},
+}
+
+// parseDetachKeys parses a detach key sequence, such as "ctrl-p,ctrl-q".
+func parseDetachKeys(val string) ([]byte, error) {
+	var keys []byte
+	for _, key := range strings.Split(val, ",") {
+		switch {
+		case len(key) == 1:
+			keys = append(keys, key[0])
+		case strings.HasPrefix(key, "ctrl-") && len(key) == 6:
+			c := key[5]
+			switch {
+			case c >= 'a' && c <= 'z':
+				keys = append(keys, c-'a'+1)
+			case c >= '@' && c <= '_':
+				keys = append(keys, c-'@')
+			default:
+				return nil, fmt.Errorf("invalid detach key %q", key)
+			}
+		default:
+			return nil, fmt.Errorf("invalid detach key %q", key)
+		}
+	}
+	return keys, nil
+}
+
+// attach connects to the attach socket at path and relays runc's stdio until
+// the container exits, in which case its exit status is returned, or the
+// client detaches, in which case 0 is returned.
+func attach(path string, readOnly bool, detachKeys []byte) (int, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return -1, errors.New("container is not attachable (use --attachable)")
+		}
+		return -1, err
+	}
+	defer conn.Close()
+
+	hello, _ := json.Marshal(attachHello{ReadOnly: readOnly})
+	if err := writeFrame(conn, frameHello, hello); err != nil {
+		return -1, err
+	}
+	typ, payload, err := readFrame(conn)
+	if err != nil {
+		return -1, fmt.Errorf("unable to attach: %w", err)
+	}
+	var reply attachHelloReply
+	if typ != frameHello {
+		return -1, errors.New("unable to attach: unexpected reply")
+	}
+	if err := json.Unmarshal(payload, &reply); err != nil {
+		return -1, fmt.Errorf("unable to attach: %w", err)
+	}
+	if reply.Error != "" {
+		return -1, fmt.Errorf("unable to attach: %s", reply.Error)
+	}
+
+	if !readOnly {
+		if reply.Terminal {
+			if cons, err := console.ConsoleFromFile(os.Stdin); err == nil {
+				if err := cons.SetRaw(); err != nil {
+					return -1, err
+				}
+				defer cons.Reset() //nolint:errcheck
+				go forwardResize(conn, cons)
+			}
+		}
+		go forwardStdin(conn, detachKeys)
+	}
+
+	for {
+		typ, payload, err := readFrame(conn)
+		if err != nil {
+			// Either we have detached, or the relay has gone away.
+			return 0, nil
+		}
+		switch typ {
+		case frameStdout:
+			_, _ = os.Stdout.Write(payload)
+		case frameStderr:
+			_, _ = os.Stderr.Write(payload)
+		case frameExit:
+			if len(payload) == 4 {
+				return int(binary.BigEndian.Uint32(payload)), nil
+			}
+			return -1, errors.New("invalid exit status")
+		}
+	}
+}
+
+// forwardResize sends the size of the terminal to the relay, initially and
+// on every SIGWINCH.
+func forwardResize(conn net.Conn, cons console.Console) {
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, unix.SIGWINCH)
+	defer signal.Stop(winch)
+	for {
+		if size, err := cons.Size(); err == nil {
+			var payload [4]byte
+			binary.BigEndian.PutUint16(payload[0:2], size.Height)
+			binary.BigEndian.PutUint16(payload[2:4], size.Width)
+			if err := writeFrame(conn, frameResize, payload[:]); err != nil {
+				return
+			}
+		}
+		<-winch
+	}
+}
+
+// forwardStdin sends runc's stdin to the relay until the detach key sequence
+// is read, in which case the connection is closed.
+func forwardStdin(conn net.Conn, detachKeys []byte) {
+	buf := make([]byte, 32*1024)
+	// matched is the number of detach keys read so far.
+	matched := 0
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n > 0 {
+			var out []byte
+			for _, b := range buf[:n] {
+				if len(detachKeys) > 0 && b == detachKeys[matched] {
+					matched++
+					if matched == len(detachKeys) {
+						_ = writeFrame(conn, frameStdin, out)
+						conn.Close()
+						return
+					}
+					continue
+				}
+				// Not a detach sequence after all, so send what was held back.
+				out = append(out, detachKeys[:matched]...)
+				matched = 0
+				if len(detachKeys) > 0 && b == detachKeys[0] {
+					matched = 1
+					continue
+				}
+				out = append(out, b)
+			}
+			if len(out) > 0 {
+				if err := writeFrame(conn, frameStdin, out); err != nil {
+					return
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -14,7 +14,9 @@ import (
 )
 
 func killContainer(container *libcontainer.Container) error {
-	_ = container.Signal(unix.SIGKILL, false)
+	// Kill all the container processes at once (atomically, if the cgroup
+	// supports it), rather than leaving it to the kernel or destroy.
+	_ = container.Signal(unix.SIGKILL, true)
 	for i := 0; i < 100; i++ {
 		time.Sleep(100 * time.Millisecond)
 		if err := container.Signal(unix.Signal(0), false); err != nil {
@@ -1,16 +1,19 @@
 package main
 
 import (
+	gocontext "context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/intelrdt"
+	"github.com/opencontainers/runc/libcontainer/utils"
 	"github.com/opencontainers/runc/types"
 
 	"github.com/sirupsen/logrus"
@@ -19,15 +22,38 @@ import (
 
 var eventsCommand = cli.Command{
 	Name:  "events",
-	Usage: "display container events such as OOM notifications, cpu, memory, and IO usage statistics",
+	Usage: "display container events such as lifecycle changes, OOM notifications, cpu, memory, and IO usage statistics",
 	ArgsUsage: `<container-id>
 
 Where "<container-id>" is the name for the instance of the container.`,
 	Description: `The events command displays information about the container. By default the
-information is displayed once every 5 seconds.`,
+information is displayed once every 5 seconds.
+
+The lifecycle events of the container (created, started, paused, resumed,
+exec-started, exec-exited, stopped and deleted) are displayed as well, starting
+with the ones which have already happened, and the command exits after the
+deleted event. Each lifecycle event has a sequence number, so that a consumer
+can resume after the last event it has seen by using --after-seq.
+
+With cgroup v2, a memory.high event is displayed when the processes have been
+throttled for exceeding the memory high boundary, and a memory.max event when
+the memory usage was about to go over the memory limit, along with the memory
+event counters.
+
+With --pressure, a pressure event is displayed every time the container tasks
+are stalled on a resource for at least the given threshold within the given
+window (see the kernel PSI documentation). The format is
+<resource>:<some|full>:<threshold>/<window>, for example memory:some:150ms/2s,
+where the resource is cpu, memory or io. This requires cgroup v2.
+
+With the openmetrics format, the stats are displayed in the OpenMetrics text
+format (each time followed by "# EOF"), and the other events are not shown.`,
 	Flags: []cli.Flag{
 		cli.DurationFlag{Name: "interval", Value: 5 * time.Second, Usage: "set the stats collection interval"},
 		cli.BoolFlag{Name: "stats", Usage: "display the container's stats then exit"},
+		cli.StringFlag{Name: "format", Value: "json", Usage: "select one of: json or openmetrics"},
+		cli.Uint64Flag{Name: "after-seq", Usage: "only display the lifecycle events with a sequence number greater than this"},
+		cli.StringSliceFlag{Name: "pressure", Usage: "display pressure events for a PSI trigger (<resource>:<some|full>:<threshold>/<window>), can be repeated"},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
@@ -45,9 +71,41 @@ information is displayed once every 5 seconds.`,
 		if err != nil {
 			return err
 		}
-		if status == libcontainer.Stopped {
+		if status == libcontainer.Stopped && context.Bool("stats") {
 			return fmt.Errorf("container with id %s is not running", container.ID())
 		}
+		var triggers []libcontainer.PSITrigger
+		for _, s := range context.StringSlice("pressure") {
+			t, err := parsePSITrigger(s)
+			if err != nil {
+				return err
+			}
+			triggers = append(triggers, t)
+		}
+		var print func(e *types.Event) error
+		switch context.String("format") {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			print = func(e *types.Event) error {
+				return enc.Encode(e)
+			}
+		case "openmetrics":
+			_, annotations := utils.Annotations(container.Config().Labels)
+			print = func(e *types.Event) error {
+				if e.Type != "stats" {
+					return nil
+				}
+				w := newOpenMetricsWriter()
+				w.addContainerMetrics(&metricsContainer{
+					id:          e.ID,
+					annotations: annotations,
+					stats:       e.Data.(*types.Stats),
+				})
+				return w.writeTo(os.Stdout)
+			}
+		default:
+			return errors.New("invalid format option")
+		}
 		var (
 			stats  = make(chan *libcontainer.Stats, 1)
 			events = make(chan *types.Event, 1024)
@@ -56,9 +114,8 @@ information is displayed once every 5 seconds.`,
 		group.Add(1)
 		go func() {
 			defer group.Done()
-			enc := json.NewEncoder(os.Stdout)
 			for e := range events {
-				if err := enc.Encode(e); err != nil {
+				if err := print(e); err != nil {
 					logrus.Error(err)
 				}
 			}
@@ -68,49 +125,186 @@ information is displayed once every 5 seconds.`,
 			if err != nil {
 				return err
 			}
-			events <- &types.Event{Type: "stats", ID: container.ID(), Data: convertLibcontainerStats(s)}
+			events <- &types.Event{Type: "stats", ID: container.ID(), Timestamp: time.Now().UTC(), Data: convertLibcontainerStats(s)}
 			close(events)
 			group.Wait()
 			return nil
 		}
-		go func() {
-			for range time.Tick(context.Duration("interval")) {
-				s, err := container.Stats()
+		ctx, cancel := gocontext.WithCancel(gocontext.Background())
+		defer cancel()
+		lifecycle, err := container.NotifyLifecycle(ctx, context.Uint64("after-seq"))
+		if err != nil {
+			return err
+		}
+		// There are no stats nor OOM and pressure notifications once the
+		// container has stopped.
+		var (
+			n         <-chan struct{}
+			memEvents <-chan cgroups.MemoryEvents
+			lastMem   *cgroups.MemoryEvents
+		)
+		pressure := make(chan libcontainer.PSITrigger)
+		statsCtx, stopStats := gocontext.WithCancel(ctx)
+		defer stopStats()
+		if status != libcontainer.Stopped {
+			go collectStats(statsCtx, container, duration, stats)
+			if n, err = container.NotifyOOM(); err != nil {
+				return err
+			}
+			if cgroups.IsCgroup2UnifiedMode() {
+				if memEvents, err = container.NotifyMemoryEvents(); err != nil {
+					logrus.Warnf("unable to get memory events: %v", err)
+				}
+			}
+			for _, t := range triggers {
+				p, err := container.NotifyPressure(ctx, t)
 				if err != nil {
-					logrus.Error(err)
-					continue
+					return err
 				}
-				stats <- s
+				go forwardPressure(ctx, t, p, pressure)
 			}
-		}()
-		n, err := container.NotifyOOM()
-		if err != nil {
-			return err
 		}
-		for {
+		for lifecycle != nil {
 			select {
+			case ev, ok := <-memEvents:
+				if !ok {
+					memEvents = nil
+					break
+				}
+				// The first counters are the ones before the events
+				// command started.
+				if lastMem != nil {
+					if ev.High > lastMem.High {
+						events <- &types.Event{Type: "memory.high", ID: container.ID(), Timestamp: time.Now().UTC(), Data: (*types.MemoryEvents)(&ev)}
+					}
+					if ev.Max > lastMem.Max {
+						events <- &types.Event{Type: "memory.max", ID: container.ID(), Timestamp: time.Now().UTC(), Data: (*types.MemoryEvents)(&ev)}
+					}
+				}
+				lastMem = &ev
+			case t := <-pressure:
+				events <- &types.Event{Type: "pressure", ID: container.ID(), Timestamp: time.Now().UTC(), Data: convertPSITrigger(t)}
 			case _, ok := <-n:
 				if ok {
 					// this means an oom event was received, if it is !ok then
 					// the channel was closed because the container stopped and
 					// the cgroups no longer exist.
-					events <- &types.Event{Type: "oom", ID: container.ID()}
+					events <- &types.Event{Type: "oom", ID: container.ID(), Timestamp: time.Now().UTC()}
 				} else {
 					n = nil
 				}
 			case s := <-stats:
-				events <- &types.Event{Type: "stats", ID: container.ID(), Data: convertLibcontainerStats(s)}
-			}
-			if n == nil {
-				close(events)
-				break
+				events <- &types.Event{Type: "stats", ID: container.ID(), Timestamp: time.Now().UTC(), Data: convertLibcontainerStats(s)}
+			case e, ok := <-lifecycle:
+				if !ok {
+					// The container has been deleted.
+					lifecycle = nil
+					break
+				}
+				if e.Type == libcontainer.EventStopped {
+					stopStats()
+				}
+				events <- convertLifecycleEvent(container.ID(), e)
 			}
 		}
+		close(events)
 		group.Wait()
 		return nil
 	},
 }
 
+// forwardPressure sends t to ch every time the PSI trigger fires, that is,
+// an event is received from p, until p is closed or ctx is done.
+func forwardPressure(ctx gocontext.Context, t libcontainer.PSITrigger, p <-chan struct{}, ch chan<- libcontainer.PSITrigger) {
+	for range p {
+		select {
+		case ch <- t:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// parsePSITrigger parses a PSI trigger in the format of --pressure, that is,
+// <resource>:<some|full>:<threshold>/<window>.
+func parsePSITrigger(s string) (libcontainer.PSITrigger, error) {
+	var t libcontainer.PSITrigger
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return t, fmt.Errorf("invalid pressure trigger %q: expected <resource>:<some|full>:<threshold>/<window>", s)
+	}
+	t.Resource = parts[0]
+	switch parts[1] {
+	case "some":
+	case "full":
+		t.Full = true
+	default:
+		return t, fmt.Errorf("invalid pressure trigger %q: expected some or full, got %q", s, parts[1])
+	}
+	threshold, window, ok := strings.Cut(parts[2], "/")
+	if !ok {
+		return t, fmt.Errorf("invalid pressure trigger %q: expected <threshold>/<window>", s)
+	}
+	var err error
+	if t.Threshold, err = time.ParseDuration(threshold); err != nil {
+		return t, fmt.Errorf("invalid pressure trigger %q: %w", s, err)
+	}
+	if t.Window, err = time.ParseDuration(window); err != nil {
+		return t, fmt.Errorf("invalid pressure trigger %q: %w", s, err)
+	}
+	if err := t.Validate(); err != nil {
+		return t, fmt.Errorf("invalid pressure trigger %q: %w", s, err)
+	}
+	return t, nil
+}
+
+// collectStats sends the stats of container to ch at every interval, until
+// ctx is done or the container has stopped.
+func collectStats(ctx gocontext.Context, container *libcontainer.Container, interval time.Duration, ch chan<- *libcontainer.Stats) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		s, err := container.Stats()
+		if err != nil {
+			if status, serr := container.Status(); serr == nil && status == libcontainer.Stopped {
+				return
+			}
+			logrus.Error(err)
+			continue
+		}
+		select {
+		case ch <- s:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func convertLifecycleEvent(id string, e libcontainer.LifecycleEvent) *types.Event {
+	ev := &types.Event{
+		Type:      string(e.Type),
+		ID:        id,
+		Seq:       e.Seq,
+		Timestamp: e.Timestamp,
+	}
+	if e.Pid != 0 || e.ExitStatus != nil {
+		l := &types.Lifecycle{Pid: e.Pid}
+		if es := e.ExitStatus; es != nil {
+			code := es.ExitCode
+			l.ExitCode = &code
+			l.Signal = es.Signal
+			l.OOMKilled = es.OOMKilled
+		}
+		ev.Data = l
+	}
+	return ev
+}
+
 func convertLibcontainerStats(ls *libcontainer.Stats) *types.Stats {
 	cg := ls.CgroupStats
 	if cg == nil {
@@ -129,6 +323,7 @@ func convertLibcontainerStats(ls *libcontainer.Stats) *types.Stats {
 	s.CPU.Throttling.Periods = cg.CpuStats.ThrottlingData.Periods
 	s.CPU.Throttling.ThrottledPeriods = cg.CpuStats.ThrottlingData.ThrottledPeriods
 	s.CPU.Throttling.ThrottledTime = cg.CpuStats.ThrottlingData.ThrottledTime
+	s.CPU.PSI = convertPSI(cg.CpuStats.PSI)
 
 	s.CPUSet = types.CPUSet(cg.CPUSetStats)
 
@@ -138,6 +333,13 @@ func convertLibcontainerStats(ls *libcontainer.Stats) *types.Stats {
 	s.Memory.Swap = convertMemoryEntry(cg.MemoryStats.SwapUsage)
 	s.Memory.Usage = convertMemoryEntry(cg.MemoryStats.Usage)
 	s.Memory.Raw = cg.MemoryStats.Stats
+	s.Memory.PSI = convertPSI(cg.MemoryStats.PSI)
+	s.Memory.High = cg.MemoryStats.High
+	s.Memory.Low = cg.MemoryStats.Low
+	s.Memory.Min = cg.MemoryStats.Min
+	if ev := cg.MemoryStats.Events; ev != nil {
+		s.Memory.Events = (*types.MemoryEvents)(ev)
+	}
 
 	s.Blkio.IoServiceBytesRecursive = convertBlkioEntry(cg.BlkioStats.IoServiceBytesRecursive)
 	s.Blkio.IoServicedRecursive = convertBlkioEntry(cg.BlkioStats.IoServicedRecursive)
@@ -147,6 +349,7 @@ func convertLibcontainerStats(ls *libcontainer.Stats) *types.Stats {
 	s.Blkio.IoMergedRecursive = convertBlkioEntry(cg.BlkioStats.IoMergedRecursive)
 	s.Blkio.IoTimeRecursive = convertBlkioEntry(cg.BlkioStats.IoTimeRecursive)
 	s.Blkio.SectorsRecursive = convertBlkioEntry(cg.BlkioStats.SectorsRecursive)
+	s.Blkio.PSI = convertPSI(cg.BlkioStats.PSI)
 
 	s.Hugetlb = make(map[string]types.Hugetlb)
 	for k, v := range cg.HugetlbStats {
@@ -193,6 +396,29 @@ func convertMemoryEntry(c cgroups.MemoryData) types.MemoryEntry {
 	}
 }
 
+func convertPSITrigger(t libcontainer.PSITrigger) *types.Pressure {
+	kind := "some"
+	if t.Full {
+		kind = "full"
+	}
+	return &types.Pressure{
+		Resource:  t.Resource,
+		Kind:      kind,
+		Threshold: uint64(t.Threshold.Microseconds()),
+		Window:    uint64(t.Window.Microseconds()),
+	}
+}
+
+func convertPSI(p *cgroups.PSIStats) *types.PSIStats {
+	if p == nil {
+		return nil
+	}
+	return &types.PSIStats{
+		Some: types.PSIData(p.Some),
+		Full: types.PSIData(p.Full),
+	}
+}
+
 func convertBlkioEntry(c []cgroups.BlkioStatEntry) []types.BlkioEntry {
 	var out []types.BlkioEntry
 	for _, e := range c {
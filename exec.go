@@ -88,6 +88,10 @@ following will output a list of processes running in the container:
 			Value: &cli.StringSlice{},
 			Usage: "add a capability to the bounding set for the process",
 		},
+		cli.StringFlag{
+			Name:  "ioprio",
+			Usage: "set the I/O scheduling class and priority for the process. Format is <class>[:<level>], where <class> is one of rt, be or idle, and <level> is 0 (highest) to 7 (lowest), 4 by default",
+		},
 		cli.IntFlag{
 			Name:  "preserve-fds",
 			Usage: "Pass N additional file descriptors to the container (stdio + $LISTEN_FDS + N in total)",
@@ -144,6 +148,35 @@ func getSubCgroupPaths(args []string) (map[string]string, error) {
 	return paths, nil
 }
 
+// parseIOPriority parses the --ioprio argument.
+func parseIOPriority(s string) (*specs.LinuxIOPriority, error) {
+	class, level, hasLevel := strings.Cut(s, ":")
+	p := &specs.LinuxIOPriority{}
+	switch class {
+	case "rt":
+		p.Class = specs.IOPRIO_CLASS_RT
+	case "be":
+		p.Class = specs.IOPRIO_CLASS_BE
+	case "idle":
+		p.Class = specs.IOPRIO_CLASS_IDLE
+		if hasLevel {
+			return nil, fmt.Errorf("invalid --ioprio argument: %s (the idle class has no level)", s)
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("invalid --ioprio argument: %s (unknown class %q)", s, class)
+	}
+	p.Priority = 4
+	if hasLevel {
+		l, err := strconv.Atoi(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --ioprio argument: %s: %w", s, err)
+		}
+		p.Priority = l
+	}
+	return p, nil
+}
+
 func execProcess(context *cli.Context) (int, error) {
 	container, err := getContainer(context)
 	if err != nil {
@@ -265,6 +298,12 @@ func getProcess(context *cli.Context, bundle string) (*specs.Process, error) {
 		}
 		p.User.UID = uint32(uid)
 	}
+	if ioprio := context.String("ioprio"); ioprio != "" {
+		p.IOPriority, err = parseIOPriority(ioprio)
+		if err != nil {
+			return nil, err
+		}
+	}
 	for _, gid := range context.Int64Slice("additional-gids") {
 		if gid < 0 {
 			return nil, fmt.Errorf("additional-gids must be a positive number %d", gid)
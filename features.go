@@ -54,6 +54,14 @@ var featuresCommand = cli.Command{
 				Selinux: &features.Selinux{
 					Enabled: &tru,
 				},
+				Personality: &features.Personality{
+					Domains: specconv.KnownPersonalityDomains(),
+				},
+				MountExtensions: &features.MountExtensions{
+					IDMap: &features.IDMap{
+						Enabled: &tru,
+					},
+				},
 			},
 		}
 
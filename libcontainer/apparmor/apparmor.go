@@ -11,6 +11,11 @@ var (
 	// on other platforms.
 	ApplyProfile = applyProfile
 
+	// ChangeProfile will apply the profile with the specified name to the
+	// current task right away. It is only supported on Linux and produces an
+	// ErrApparmorNotEnabled on other platforms.
+	ChangeProfile = changeProfile
+
 	// ErrApparmorNotEnabled indicates that AppArmor is not enabled or not supported.
 	ErrApparmorNotEnabled = errors.New("apparmor: config provided but apparmor not supported")
 )
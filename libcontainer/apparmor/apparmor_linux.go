@@ -66,3 +66,17 @@ func applyProfile(name string) error {
 
 	return changeOnExec(name)
 }
+
+// changeProfile reimplements aa_change_profile from libapparmor in Go. Unlike
+// applyProfile, it applies the profile with the specified name to the current
+// task right away.
+func changeProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	if err := setProcAttr("current", "changeprofile "+name); err != nil {
+		return fmt.Errorf("apparmor failed to change profile: %w", err)
+	}
+	return nil
+}
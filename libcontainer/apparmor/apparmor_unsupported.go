@@ -13,3 +13,10 @@ func applyProfile(name string) error {
 	}
 	return nil
 }
+
+func changeProfile(name string) error {
+	if name != "" {
+		return ErrApparmorNotEnabled
+	}
+	return nil
+}
@@ -11,6 +11,11 @@ var (
 	// is not configured to set device rules.
 	ErrDevicesUnsupported = errors.New("cgroup manager is not configured to set device rules")
 
+	// ErrKillUnsupported is an error returned by Manager.Kill when the
+	// cgroup can not be killed atomically (cgroup v1, or cgroup v2 on
+	// kernels before 5.14, which lack cgroup.kill).
+	ErrKillUnsupported = errors.New("cgroup kill is not supported")
+
 	// DevicesSetV1 and DevicesSetV2 are functions to set devices for
 	// cgroup v1 and v2, respectively. Unless libcontainer/cgroups/devices
 	// package is imported, it is set to nil, so cgroup managers can't
@@ -71,4 +76,9 @@ type Manager interface {
 
 	// OOMKillCount reports OOM kill count for the cgroup.
 	OOMKillCount() (uint64, error)
+
+	// Kill atomically kills all processes inside the cgroup and all its
+	// sub-cgroups with SIGKILL, using the cgroup.kill file, and waits for
+	// them to exit. It returns ErrKillUnsupported if this is not possible.
+	Kill() error
 }
@@ -249,6 +249,11 @@ func (m *Manager) Exists() bool {
 	return cgroups.PathExists(m.Path("devices"))
 }
 
+// Kill is not supported on cgroup v1.
+func (m *Manager) Kill() error {
+	return cgroups.ErrKillUnsupported
+}
+
 func OOMKillCount(path string) (uint64, error) {
 	return fscommon.GetValueByKey(path, "memory.oom_control", "oom_kill")
 }
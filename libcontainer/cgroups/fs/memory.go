@@ -115,8 +115,10 @@ func (s *MemoryGroup) Set(path string, r *configs.Resources) error {
 
 	// ignore KernelMemory and KernelMemoryTCP
 
-	if r.MemoryReservation != 0 {
-		if err := cgroups.WriteFile(path, "memory.soft_limit_in_bytes", strconv.FormatInt(r.MemoryReservation, 10)); err != nil {
+	// cgroup v1 has no memory.low and memory.min, so the soft limit is the
+	// best-effort stand-in for them, and MemoryHigh is ignored.
+	if softLimit := softLimit(r); softLimit != 0 {
+		if err := cgroups.WriteFile(path, "memory.soft_limit_in_bytes", strconv.FormatInt(softLimit, 10)); err != nil {
 			return err
 		}
 	}
@@ -139,6 +141,27 @@ func (s *MemoryGroup) Set(path string, r *configs.Resources) error {
 	return nil
 }
 
+// softLimit returns the value for memory.soft_limit_in_bytes, which is
+// MemoryReservation, or else MemoryLow, or else MemoryMin. If MemoryLow or
+// MemoryMin are set to 0 and none of them is non-zero, the soft limit is
+// removed (-1). 0 means the soft limit is left as is.
+func softLimit(r *configs.Resources) int64 {
+	if r.MemoryReservation != 0 {
+		return r.MemoryReservation
+	}
+	var limit int64
+	for _, v := range []*int64{r.MemoryLow, r.MemoryMin} {
+		if v == nil {
+			continue
+		}
+		if *v != 0 {
+			return *v
+		}
+		limit = -1
+	}
+	return limit
+}
+
 func (s *MemoryGroup) GetStats(path string, stats *cgroups.Stats) error {
 	const file = "memory.stat"
 	statsFile, err := cgroups.OpenFile(path, file, os.O_RDONLY)
@@ -79,6 +79,56 @@ func TestMemorySetMemory(t *testing.T) {
 	}
 }
 
+func TestMemorySetMemoryLow(t *testing.T) {
+	path := tempDir(t, "memory")
+
+	const (
+		softLimitBefore = 209715200 // 200M
+		lowAfter        = 314572800 // 300M
+	)
+
+	writeFileContents(t, path, map[string]string{
+		"memory.soft_limit_in_bytes": strconv.Itoa(softLimitBefore),
+	})
+
+	high, low, min := int64(-1), int64(lowAfter), int64(softLimitBefore)
+	r := &configs.Resources{
+		MemoryHigh: &high,
+		MemoryLow:  &low,
+		MemoryMin:  &min,
+	}
+	memory := &MemoryGroup{}
+	if err := memory.Set(path, r); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := fscommon.GetCgroupParamUint(path, "memory.soft_limit_in_bytes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != lowAfter {
+		t.Fatal("Got the wrong value, set memory.soft_limit_in_bytes failed.")
+	}
+
+	// Zero values remove the soft limit.
+	zero := int64(0)
+	r = &configs.Resources{
+		MemoryLow: &zero,
+		MemoryMin: &zero,
+	}
+	if err := memory.Set(path, r); err != nil {
+		t.Fatal(err)
+	}
+
+	str, err := fscommon.GetCgroupParamString(path, "memory.soft_limit_in_bytes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if str != "-1" {
+		t.Fatalf("expected memory.soft_limit_in_bytes to be -1, got %q", str)
+	}
+}
+
 func TestMemorySetMemoryswap(t *testing.T) {
 	path := tempDir(t, "memory")
 
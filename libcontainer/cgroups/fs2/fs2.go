@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/cgroups/fscommon"
@@ -122,6 +123,17 @@ func (m *Manager) GetStats() (*cgroups.Stats, error) {
 	if err := fscommon.RdmaGetStats(m.dirPath, st); err != nil && !os.IsNotExist(err) {
 		errs = append(errs, err)
 	}
+	// PSI (since kernel 4.20)
+	var err error
+	if st.CpuStats.PSI, err = statPSI(m.dirPath, "cpu.pressure"); err != nil {
+		errs = append(errs, err)
+	}
+	if st.MemoryStats.PSI, err = statPSI(m.dirPath, "memory.pressure"); err != nil {
+		errs = append(errs, err)
+	}
+	if st.BlkioStats.PSI, err = statPSI(m.dirPath, "io.pressure"); err != nil {
+		errs = append(errs, err)
+	}
 	if len(errs) > 0 && !m.config.Rootless {
 		return st, fmt.Errorf("error while statting cgroup v2: %+v", errs)
 	}
@@ -270,6 +282,47 @@ func (m *Manager) OOMKillCount() (uint64, error) {
 	return c, err
 }
 
+// Kill implements cgroups.Manager.Kill using the cgroup.kill file, available
+// since Linux 5.14, and waits for the cgroup to have no more processes.
+func (m *Manager) Kill() error {
+	err := cgroups.WriteFile(m.dirPath, "cgroup.kill", "1")
+	if errors.Is(err, os.ErrNotExist) {
+		if !m.Exists() {
+			// No cgroup, nothing to kill.
+			return nil
+		}
+		return cgroups.ErrKillUnsupported
+	}
+	if err != nil {
+		return err
+	}
+	return waitUnpopulated(m.dirPath)
+}
+
+// waitUnpopulated polls cgroup.events until it sees "populated 0" in it (or
+// the cgroup is gone).
+func waitUnpopulated(dirPath string) error {
+	const (
+		// Perform maxIter with waitTime in between iterations.
+		waitTime = 10 * time.Millisecond
+		maxIter  = 1000
+	)
+	for i := 0; i < maxIter; i++ {
+		populated, err := fscommon.GetValueByKey(dirPath, "cgroup.events", "populated")
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if populated == 0 {
+			return nil
+		}
+		time.Sleep(waitTime)
+	}
+	return fmt.Errorf("timeout of %s reached waiting for the cgroup processes to exit", waitTime*maxIter)
+}
+
 func CheckMemoryUsage(dirPath string, r *configs.Resources) error {
 	if !r.MemoryCheckBeforeUpdate {
 		return nil
@@ -20,7 +20,8 @@ func isIoSet(r *configs.Resources) bool {
 		len(r.BlkioThrottleReadBpsDevice) > 0 ||
 		len(r.BlkioThrottleWriteBpsDevice) > 0 ||
 		len(r.BlkioThrottleReadIOPSDevice) > 0 ||
-		len(r.BlkioThrottleWriteIOPSDevice) > 0
+		len(r.BlkioThrottleWriteIOPSDevice) > 0 ||
+		len(r.IOLatencyDevice) > 0
 }
 
 // bfqDeviceWeightSupported checks for per-device BFQ weight support (added
@@ -73,6 +74,17 @@ func setIo(dirPath string, r *configs.Resources) error {
 				return fmt.Errorf("setting device weight %q: %w", wd.WeightString(), err)
 			}
 		}
+	} else {
+		// Fallback to io.weight with the same conversion scheme.
+		for _, wd := range r.BlkioWeightDevice {
+			if wd.Weight == 0 {
+				continue
+			}
+			v := cgroups.ConvertBlkIOToIOWeightValue(wd.Weight)
+			if err := cgroups.WriteFile(dirPath, "io.weight", fmt.Sprintf("%d:%d %d", wd.Major, wd.Minor, v)); err != nil {
+				return fmt.Errorf("setting device weight %q: %w", wd.WeightString(), err)
+			}
+		}
 	}
 	for _, td := range r.BlkioThrottleReadBpsDevice {
 		if err := cgroups.WriteFile(dirPath, "io.max", td.StringName("rbps")); err != nil {
@@ -94,6 +106,11 @@ func setIo(dirPath string, r *configs.Resources) error {
 			return err
 		}
 	}
+	for _, ld := range r.IOLatencyDevice {
+		if err := cgroups.WriteFile(dirPath, "io.latency", ld.String()); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
 )
 
 const exampleIoStatData = `254:1 rbytes=6901432320 wbytes=14245535744 rios=263278 wios=248603 dbytes=0 dios=0
@@ -79,3 +80,32 @@ func TestStatIo(t *testing.T) {
 		t.Errorf("parsed cgroupv2 io.stat doesn't match expected result: \ngot %#v\nexpected %#v\n", gotStats.BlkioStats, exampleIoStatsParsed)
 	}
 }
+
+func TestSetIo(t *testing.T) {
+	// We're using a fake cgroupfs.
+	cgroups.TestMode = true
+
+	fakeCgroupDir := t.TempDir()
+	r := &configs.Resources{
+		BlkioThrottleReadBpsDevice: []*configs.ThrottleDevice{configs.NewThrottleDevice(8, 0, 0)},
+		IOLatencyDevice:            []*configs.LatencyDevice{configs.NewLatencyDevice(8, 16, 25000)},
+		// Without io.bfq.weight, io.weight is used instead.
+		BlkioWeightDevice: []*configs.WeightDevice{configs.NewWeightDevice(8, 0, 500, 0)},
+	}
+	if err := setIo(fakeCgroupDir, r); err != nil {
+		t.Fatal(err)
+	}
+	for file, expected := range map[string]string{
+		"io.max":     "8:0 rbps=max",
+		"io.latency": "8:16 target=25000",
+		"io.weight":  "8:0 4950",
+	} {
+		value, err := cgroups.ReadFile(fakeCgroupDir, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != expected {
+			t.Errorf("expected %s to be %q, got %q", file, expected, value)
+		}
+	}
+}
@@ -33,7 +33,8 @@ func numToStr(value int64) (ret string) {
 }
 
 func isMemorySet(r *configs.Resources) bool {
-	return r.MemoryReservation != 0 || r.Memory != 0 || r.MemorySwap != 0
+	return r.MemoryReservation != 0 || r.Memory != 0 || r.MemorySwap != 0 ||
+		r.MemoryHigh != nil || r.MemoryLow != nil || r.MemoryMin != nil
 }
 
 func setMemory(dirPath string, r *configs.Resources) error {
@@ -69,8 +70,31 @@ func setMemory(dirPath string, r *configs.Resources) error {
 
 	// cgroup.Resources.KernelMemory is ignored
 
-	if val := numToStr(r.MemoryReservation); val != "" {
-		if err := cgroups.WriteFile(dirPath, "memory.low", val); err != nil {
+	// MemoryLow takes precedence over MemoryReservation.
+	if r.MemoryLow == nil {
+		if val := numToStr(r.MemoryReservation); val != "" {
+			if err := cgroups.WriteFile(dirPath, "memory.low", val); err != nil {
+				return err
+			}
+		}
+	}
+	for _, pair := range []struct {
+		file  string
+		value *int64
+	}{
+		{"memory.high", r.MemoryHigh},
+		{"memory.low", r.MemoryLow},
+		{"memory.min", r.MemoryMin},
+	} {
+		if pair.value == nil {
+			continue
+		}
+		// Unlike with numToStr, 0 is written as is, to remove the protection.
+		val := "0"
+		if *pair.value != 0 {
+			val = numToStr(*pair.value)
+		}
+		if err := cgroups.WriteFile(dirPath, pair.file, val); err != nil {
 			return err
 		}
 	}
@@ -98,6 +122,30 @@ func statMemory(dirPath string, stats *cgroups.Stats) error {
 		return &parseError{Path: dirPath, File: file, Err: err}
 	}
 	stats.MemoryStats.Cache = stats.MemoryStats.Stats["file"]
+	// The root cgroup does not have memory.events.
+	ev, err := GetMemoryEvents(dirPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	stats.MemoryStats.Events = ev
+	// The root cgroup does not have memory.{high,low,min} either.
+	for _, pair := range []struct {
+		file string
+		dest *uint64
+	}{
+		{"memory.high", &stats.MemoryStats.High},
+		{"memory.low", &stats.MemoryStats.Low},
+		{"memory.min", &stats.MemoryStats.Min},
+	} {
+		v, err := fscommon.GetCgroupParamUint(dirPath, pair.file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		*pair.dest = v
+	}
 	// Unlike cgroup v1 which has memory.use_hierarchy binary knob,
 	// cgroup v2 is always hierarchical.
 	stats.MemoryStats.UseHierarchy = true
@@ -128,6 +176,43 @@ func statMemory(dirPath string, stats *cgroups.Stats) error {
 	return nil
 }
 
+// GetMemoryEvents reads the memory event counters of the cgroup path.
+func GetMemoryEvents(path string) (*cgroups.MemoryEvents, error) {
+	const file = "memory.events"
+	f, err := cgroups.OpenFile(path, file, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ev cgroups.MemoryEvents
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		k, v, err := fscommon.ParseKeyValue(sc.Text())
+		if err != nil {
+			return nil, &parseError{Path: path, File: file, Err: err}
+		}
+		switch k {
+		case "low":
+			ev.Low = v
+		case "high":
+			ev.High = v
+		case "max":
+			ev.Max = v
+		case "oom":
+			ev.OOM = v
+		case "oom_kill":
+			ev.OOMKill = v
+		case "oom_group_kill":
+			ev.OOMGroupKill = v
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, &parseError{Path: path, File: file, Err: err}
+	}
+	return &ev, nil
+}
+
 func getMemoryDataV2(path, name string) (cgroups.MemoryData, error) {
 	memoryData := cgroups.MemoryData{}
 
@@ -0,0 +1,83 @@
+package fs2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestGetMemoryEvents(t *testing.T) {
+	// We're using a fake cgroupfs.
+	cgroups.TestMode = true
+
+	fakeCgroupDir := t.TempDir()
+	const data = `low 1
+high 22
+max 333
+oom 4
+oom_kill 5
+oom_group_kill 6
+`
+	if err := os.WriteFile(filepath.Join(fakeCgroupDir, "memory.events"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ev, err := GetMemoryEvents(fakeCgroupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := cgroups.MemoryEvents{Low: 1, High: 22, Max: 333, OOM: 4, OOMKill: 5, OOMGroupKill: 6}
+	if *ev != expected {
+		t.Errorf("expected %+v, got %+v", expected, *ev)
+	}
+}
+
+func TestSetMemoryProtection(t *testing.T) {
+	// We're using a fake cgroupfs.
+	cgroups.TestMode = true
+
+	fakeCgroupDir := t.TempDir()
+	high, low, min := int64(-1), int64(4096), int64(2048)
+	r := &configs.Resources{
+		MemoryReservation: 1024,
+		MemoryHigh:        &high,
+		MemoryLow:         &low,
+		MemoryMin:         &min,
+	}
+	if err := setMemory(fakeCgroupDir, r); err != nil {
+		t.Fatal(err)
+	}
+	checkMemoryFiles(t, fakeCgroupDir, map[string]string{
+		"memory.high": "max",
+		"memory.low":  "4096",
+		"memory.min":  "2048",
+	})
+
+	// Zero values remove the protection.
+	zero := int64(0)
+	r = &configs.Resources{MemoryLow: &zero, MemoryMin: &zero}
+	if err := setMemory(fakeCgroupDir, r); err != nil {
+		t.Fatal(err)
+	}
+	checkMemoryFiles(t, fakeCgroupDir, map[string]string{
+		"memory.high": "max",
+		"memory.low":  "0",
+		"memory.min":  "0",
+	})
+}
+
+func checkMemoryFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for file, expected := range files {
+		value, err := cgroups.ReadFile(dir, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != expected {
+			t.Errorf("expected %s to be %q, got %q", file, expected, value)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package fs2
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+)
+
+// statPSI reads the pressure stall information from the given file (such as
+// "cpu.pressure"). It returns nil stats if they are not available.
+func statPSI(dirPath string, file string) (*cgroups.PSIStats, error) {
+	f, err := cgroups.OpenFile(dirPath, file, os.O_RDONLY)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Kernel < 4.20, or CONFIG_PSI is not set, or PSI is disabled
+			// for the cgroup ("echo 0 > cgroup.pressure", kernel >= 6.1).
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var st cgroups.PSIStats
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		kind, data, _ := strings.Cut(sc.Text(), " ")
+		var d *cgroups.PSIData
+		switch kind {
+		case "some":
+			d = &st.Some
+		case "full":
+			d = &st.Full
+		default:
+			continue
+		}
+		if *d, err = parsePSIData(data); err != nil {
+			return nil, &parseError{Path: dirPath, File: file, Err: err}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			// The kernel was built with CONFIG_PSI_DEFAULT_DISABLED,
+			// and booted without psi=1.
+			return nil, nil
+		}
+		return nil, &parseError{Path: dirPath, File: file, Err: err}
+	}
+	return &st, nil
+}
+
+// parsePSIData parses a line of a pressure file, without its "some" or
+// "full" prefix, e.g. "avg10=0.00 avg60=0.00 avg300=0.00 total=0".
+func parsePSIData(line string) (cgroups.PSIData, error) {
+	var data cgroups.PSIData
+	for _, field := range strings.Fields(line) {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			return data, fmt.Errorf("invalid PSI data: %q", field)
+		}
+		var avg *float64
+		switch key {
+		case "avg10":
+			avg = &data.Avg10
+		case "avg60":
+			avg = &data.Avg60
+		case "avg300":
+			avg = &data.Avg300
+		case "total":
+			v, err := strconv.ParseUint(val, 10, 64)
+			if err != nil {
+				return data, fmt.Errorf("invalid PSI total: %w", err)
+			}
+			data.Total = v
+		}
+		if avg != nil {
+			v, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return data, fmt.Errorf("invalid PSI %s: %w", key, err)
+			}
+			*avg = v
+		}
+	}
+	return data, nil
+}
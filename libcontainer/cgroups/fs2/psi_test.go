@@ -0,0 +1,48 @@
+package fs2
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+)
+
+func TestStatPSI(t *testing.T) {
+	// We're using a fake cgroupfs.
+	cgroups.TestMode = true
+
+	fakeCgroupDir := t.TempDir()
+	const data = `some avg10=29.30 avg60=50.10 avg300=15.00 total=123456
+full avg10=0.00 avg60=0.10 avg300=0.25 total=789
+`
+	if err := os.WriteFile(filepath.Join(fakeCgroupDir, "memory.pressure"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := statPSI(fakeCgroupDir, "memory.pressure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &cgroups.PSIStats{
+		Some: cgroups.PSIData{Avg10: 29.3, Avg60: 50.1, Avg300: 15, Total: 123456},
+		Full: cgroups.PSIData{Avg60: 0.1, Avg300: 0.25, Total: 789},
+	}
+	if !reflect.DeepEqual(st, expected) {
+		t.Errorf("expected %+v, got %+v", expected, st)
+	}
+
+	// A missing file is not an error.
+	st, err = statPSI(fakeCgroupDir, "io.pressure")
+	if err != nil || st != nil {
+		t.Errorf("expected no stats and no error, got %+v, %v", st, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fakeCgroupDir, "cpu.pressure"), []byte("some avg10=x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := statPSI(fakeCgroupDir, "cpu.pressure"); err == nil {
+		t.Error("expected an error for invalid data")
+	}
+}
@@ -25,3 +25,32 @@ func GetAllPids(path string) ([]int, error) {
 	})
 	return pids, err
 }
+
+// GetPidsByCgroup is like GetAllPids, but returns the pids grouped by the
+// cgroup they are in, identified by its path relative to path ("/" being the
+// cgroup identified by path itself).
+func GetPidsByCgroup(path string) (map[string][]int, error) {
+	pids := make(map[string][]int)
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, iErr error) error {
+		if iErr != nil {
+			return iErr
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		cPids, err := readProcsFile(p)
+		if err != nil {
+			return err
+		}
+		if len(cPids) > 0 {
+			rel, err := filepath.Rel(path, p)
+			if err != nil {
+				return err
+			}
+			cg := filepath.Join("/", rel)
+			pids[cg] = append(pids[cg], cPids...)
+		}
+		return nil
+	})
+	return pids, err
+}
@@ -1,6 +1,9 @@
 package cgroups
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -15,3 +18,31 @@ func BenchmarkGetAllPids(b *testing.B) {
 	}
 	b.Logf("iter: %d, total: %d", b.N, total)
 }
+
+func TestGetPidsByCgroup(t *testing.T) {
+	// We're using a fake cgroupfs.
+	TestMode = true
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a", "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, data := range map[string]string{
+		"cgroup.procs":        "1\n2\n",
+		"a/cgroup.procs":      "",
+		"a/b/cgroup.procs":    "3\n",
+		"a/b/cgroup.controls": "",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pids, err := GetPidsByCgroup(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string][]int{"/": {1, 2}, "/a/b": {3}}
+	if !reflect.DeepEqual(pids, exp) {
+		t.Fatalf("expected %v, got %v", exp, pids)
+	}
+}
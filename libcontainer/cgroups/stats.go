@@ -32,9 +32,31 @@ type CpuUsage struct {
 	UsageInUsermode uint64 `json:"usage_in_usermode"`
 }
 
+// PSIData are the pressure stall information (see
+// Documentation/accounting/psi.rst in the kernel sources) of a resource,
+// for the time some or all of the tasks were stalled.
+type PSIData struct {
+	// The share of time (in percent) tasks were stalled, averaged over the
+	// last 10, 60 and 300 seconds.
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	// Total stall time.
+	// Units: microseconds.
+	Total uint64 `json:"total"`
+}
+
+// PSIStats are the pressure stall information of a resource, for the time
+// some tasks and all (non-idle) tasks were stalled on it.
+type PSIStats struct {
+	Some PSIData `json:"some,omitempty"`
+	Full PSIData `json:"full,omitempty"`
+}
+
 type CpuStats struct {
 	CpuUsage       CpuUsage       `json:"cpu_usage,omitempty"`
 	ThrottlingData ThrottlingData `json:"throttling_data,omitempty"`
+	PSI            *PSIStats      `json:"psi,omitempty"`
 }
 
 type CPUSetStats struct {
@@ -89,6 +111,35 @@ type MemoryStats struct {
 	UseHierarchy bool `json:"use_hierarchy"`
 
 	Stats map[string]uint64 `json:"stats,omitempty"`
+
+	PSI *PSIStats `json:"psi,omitempty"`
+
+	// Events are the memory event counters (cgroup v2 only).
+	Events *MemoryEvents `json:"events,omitempty"`
+
+	// The memory.high, memory.low and memory.min boundaries (cgroup v2
+	// only), with math.MaxUint64 meaning "max".
+	High uint64 `json:"high,omitempty"`
+	Low  uint64 `json:"low,omitempty"`
+	Min  uint64 `json:"min,omitempty"`
+}
+
+// MemoryEvents are the numbers of memory events of a cgroup (and its
+// descendants), see memory.events in the cgroup v2 documentation.
+type MemoryEvents struct {
+	// Number of times the cgroup was reclaimed while below its low boundary.
+	Low uint64 `json:"low"`
+	// Number of times the processes were throttled and routed to reclaim
+	// because the high boundary was exceeded.
+	High uint64 `json:"high"`
+	// Number of times the usage was about to go over the max boundary.
+	Max uint64 `json:"max"`
+	// Number of times the usage hit the limit and allocations failed.
+	OOM uint64 `json:"oom"`
+	// Number of processes killed by the OOM killer.
+	OOMKill uint64 `json:"oom_kill"`
+	// Number of times a group OOM occurred.
+	OOMGroupKill uint64 `json:"oom_group_kill"`
 }
 
 type PageUsageByNUMA struct {
@@ -133,6 +184,7 @@ type BlkioStats struct {
 	IoMergedRecursive       []BlkioStatEntry `json:"io_merged_recursive,omitempty"`
 	IoTimeRecursive         []BlkioStatEntry `json:"io_time_recursive,omitempty"`
 	SectorsRecursive        []BlkioStatEntry `json:"sectors_recursive,omitempty"`
+	PSI                     *PSIStats        `json:"psi,omitempty"`
 }
 
 type HugetlbStats struct {
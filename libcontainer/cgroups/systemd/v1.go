@@ -408,3 +408,8 @@ func (m *LegacyManager) Exists() bool {
 func (m *LegacyManager) OOMKillCount() (uint64, error) {
 	return fs.OOMKillCount(m.Path("memory"))
 }
+
+// Kill is not supported on cgroup v1.
+func (m *LegacyManager) Kill() error {
+	return cgroups.ErrKillUnsupported
+}
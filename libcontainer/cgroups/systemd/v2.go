@@ -199,10 +199,22 @@ func genV2ResourcesProperties(dirPath string, r *configs.Resources, cm *dbusConn
 		properties = append(properties,
 			newProp("MemoryMax", uint64(r.Memory)))
 	}
-	if r.MemoryReservation != 0 {
+	if r.MemoryHigh != nil {
+		properties = append(properties,
+			newProp("MemoryHigh", uint64(*r.MemoryHigh)))
+	}
+	// MemoryLow takes precedence over MemoryReservation.
+	if r.MemoryLow != nil {
+		properties = append(properties,
+			newProp("MemoryLow", uint64(*r.MemoryLow)))
+	} else if r.MemoryReservation != 0 {
 		properties = append(properties,
 			newProp("MemoryLow", uint64(r.MemoryReservation)))
 	}
+	if r.MemoryMin != nil {
+		properties = append(properties,
+			newProp("MemoryMin", uint64(*r.MemoryMin)))
+	}
 
 	swap, err := cgroups.ConvertMemorySwapToCgroupV2Value(r.MemorySwap, r.Memory)
 	if err != nil {
@@ -477,3 +489,7 @@ func (m *UnifiedManager) Exists() bool {
 func (m *UnifiedManager) OOMKillCount() (uint64, error) {
 	return m.fsMgr.OOMKillCount()
 }
+
+func (m *UnifiedManager) Kill() error {
+	return m.fsMgr.Kill()
+}
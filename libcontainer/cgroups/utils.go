@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -417,6 +418,58 @@ func WriteCgroupProc(dir string, pid int) error {
 	return err
 }
 
+// CheckMemoryBoundaries checks that min <= low <= high <= max for the
+// memory boundaries which are set, where nil or 0 is "unset" and -1 is "max".
+func CheckMemoryBoundaries(min, low, high *int64, max int64) error {
+	deref := func(v *int64) int64 {
+		if v == nil {
+			return 0
+		}
+		return *v
+	}
+	bounds := []struct {
+		name  string
+		value int64
+	}{
+		{"memory.min", deref(min)},
+		{"memory.low", deref(low)},
+		{"memory.high", deref(high)},
+		{"memory.max", max},
+	}
+	value := func(v int64) int64 {
+		if v == -1 {
+			return math.MaxInt64
+		}
+		return v
+	}
+	prev := -1
+	for i, b := range bounds {
+		if b.value < -1 {
+			return fmt.Errorf("invalid %s value: %d", b.name, b.value)
+		}
+		if b.value == 0 {
+			continue
+		}
+		if prev != -1 && value(bounds[prev].value) > value(b.value) {
+			return fmt.Errorf("%s (%d) must not be greater than %s (%d)", bounds[prev].name, bounds[prev].value, b.name, b.value)
+		}
+		prev = i
+	}
+	return nil
+}
+
+// CheckBlockDevice checks that major:minor refers to a block device known
+// to the kernel.
+func CheckBlockDevice(major, minor int64) error {
+	if _, err := os.Stat(fmt.Sprintf("/sys/dev/block/%d:%d", major, minor)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%d:%d is not a block device", major, minor)
+		}
+		return err
+	}
+	return nil
+}
+
 // Since the OCI spec is designed for cgroup v1, in some cases
 // there is need to convert from the cgroup v1 configuration to cgroup v2
 // the formula for cpuShares is y = (1 + ((x - 2) * 9999) / 262142)
@@ -60,7 +60,36 @@ func (td *ThrottleDevice) String() string {
 	return fmt.Sprintf("%d:%d %d", td.Major, td.Minor, td.Rate)
 }
 
-// StringName formats the struct to be writable to the cgroup specific file
+// StringName formats the struct to be writable to the cgroup v2 io.max file.
+// As in cgroup v1, a zero rate means no limit ("max").
 func (td *ThrottleDevice) StringName(name string) string {
+	if td.Rate == 0 {
+		return fmt.Sprintf("%d:%d %s=max", td.Major, td.Minor, name)
+	}
 	return fmt.Sprintf("%d:%d %s=%d", td.Major, td.Minor, name, td.Rate)
 }
+
+// LatencyDevice struct holds a `major:minor target` pair
+type LatencyDevice struct {
+	BlockIODevice
+	// Target is the IO latency target per cgroup per device, in
+	// microseconds; 0 means no target
+	Target uint64 `json:"target"`
+}
+
+// NewLatencyDevice returns a configured LatencyDevice pointer
+func NewLatencyDevice(major, minor int64, target uint64) *LatencyDevice {
+	ld := &LatencyDevice{}
+	ld.Major = major
+	ld.Minor = minor
+	ld.Target = target
+	return ld
+}
+
+// String formats the struct to be writable to the cgroup v2 io.latency file
+func (ld *LatencyDevice) String() string {
+	if ld.Target == 0 {
+		return fmt.Sprintf("%d:%d target=max", ld.Major, ld.Minor)
+	}
+	return fmt.Sprintf("%d:%d target=%d", ld.Major, ld.Minor, ld.Target)
+}
@@ -63,6 +63,24 @@ type Resources struct {
 	// Total memory usage (memory + swap); set `-1` to enable unlimited swap
 	MemorySwap int64 `json:"memory_swap"`
 
+	// Memory usage throttle limit (in bytes), above which the processes are
+	// throttled and put under heavy reclaim pressure; set `-1` for no limit,
+	// nil to leave it unchanged. On cgroup v1, it is not supported and ignored.
+	MemoryHigh *int64 `json:"memory_high,omitempty"`
+
+	// Best-effort memory protection (in bytes); memory below it is only
+	// reclaimed if there is no unprotected reclaimable memory. Set `0` for no
+	// protection, nil to leave it unchanged. On cgroup v1,
+	// memory.soft_limit_in_bytes is used as a stand-in (if
+	// MemoryReservation is not set).
+	MemoryLow *int64 `json:"memory_low,omitempty"`
+
+	// Hard memory protection (in bytes); memory below it is never
+	// reclaimed. Set `0` for no protection, nil to leave it unchanged.
+	// On cgroup v1, memory.soft_limit_in_bytes is used as a stand-in
+	// (if neither MemoryReservation nor MemoryLow are set).
+	MemoryMin *int64 `json:"memory_min,omitempty"`
+
 	// CPU shares (relative weight vs. other containers)
 	CpuShares uint64 `json:"cpu_shares"`
 
@@ -108,6 +126,9 @@ type Resources struct {
 	// IO write rate limit per cgroup per device, IO per second.
 	BlkioThrottleWriteIOPSDevice []*ThrottleDevice `json:"blkio_throttle_write_iops_device"`
 
+	// IO latency target per cgroup per device (cgroup v2 only).
+	IOLatencyDevice []*LatencyDevice `json:"io_latency_device,omitempty"`
+
 	// set the freeze value for the process
 	Freezer FreezerState `json:"freezer"`
 
@@ -19,6 +19,59 @@ type Rlimit struct {
 	Soft uint64 `json:"soft"`
 }
 
+// Execution domains, as set with personality(2).
+const (
+	PerLinux   = 0x0000
+	PerLinux32 = 0x0008
+)
+
+// LinuxPersonality is the personality of the container processes.
+type LinuxPersonality struct {
+	// Domain is the execution domain, e.g. PerLinux32 to report a 32-bit
+	// architecture (such as i686 rather than x86_64) to the processes.
+	Domain int `json:"domain"`
+}
+
+// Scheduler is the scheduling policy and attributes of the container
+// processes, see sched_setattr(2).
+type Scheduler = specs.Scheduler
+
+// IOPriority is the I/O scheduling class and priority of the container
+// processes, see ioprio_set(2).
+type IOPriority = specs.LinuxIOPriority
+
+// Procfs are the options of a private procfs instance (see proc(5)), which
+// require Linux 5.8.
+type Procfs struct {
+	// SubsetPid only shows the process directories (subset=pid), so that
+	// the other files and directories (such as /proc/sys or /proc/kcore)
+	// never appear, rather than having to be masked.
+	SubsetPid bool `json:"subset_pid,omitempty"`
+
+	// HidePid is the hidepid option, that is, "off", "noaccess", "invisible"
+	// or "ptraceable".
+	HidePid string `json:"hidepid,omitempty"`
+}
+
+// Landlock is a Landlock ruleset (see landlock(7)), which restricts the
+// filesystem accesses of the container processes.
+type Landlock struct {
+	// HandledAccessFS are the filesystem access rights (such as "read_file"
+	// or "write_file") handled by the ruleset, that is, denied unless a rule
+	// allows them. If empty, all the rights known to the kernel are handled.
+	HandledAccessFS []string `json:"handled_access_fs,omitempty"`
+
+	// Rules allow some of the handled access rights beneath some paths.
+	Rules []LandlockRule `json:"rules,omitempty"`
+}
+
+// LandlockRule allows filesystem access rights beneath paths (or on files)
+// of the container.
+type LandlockRule struct {
+	AllowedAccess []string `json:"allowed_access"`
+	Paths         []string `json:"paths"`
+}
+
 // IDMap represents UID/GID Mappings for User Namespaces.
 type IDMap struct {
 	ContainerID int `json:"container_id"`
@@ -119,6 +172,9 @@ type Config struct {
 	// Hostname optionally sets the container's hostname if provided
 	Hostname string `json:"hostname"`
 
+	// Domainname optionally sets the container's NIS domain name if provided
+	Domainname string `json:"domainname,omitempty"`
+
 	// Namespaces specifies the container's namespaces that it should setup when cloning the init process
 	// If a namespace is not provided that namespace is shared from the container's parent process
 	Namespaces Namespaces `json:"namespaces"`
@@ -170,6 +226,10 @@ type Config struct {
 	// so that these files prevent any writes.
 	ReadonlyPaths []string `json:"readonly_paths"`
 
+	// Procfs specifies the options of the procfs instance mounted on the
+	// container's /proc. If nil, it is a full procfs instance.
+	Procfs *Procfs `json:"procfs,omitempty"`
+
 	// Sysctl is a map of properties and their values. It is the equivalent of using
 	// sysctl -w my.property.name value in Linux.
 	Sysctl map[string]string `json:"sysctl"`
@@ -196,6 +256,45 @@ type Config struct {
 	// callers keyring in this case.
 	NoNewKeyring bool `json:"no_new_keyring"`
 
+	// Init makes runc stay as the container's init process, running the
+	// container process as its child rather than exec-ing it. It reaps the
+	// zombie processes of the container, forwards signals to the container
+	// process, and exits with its exit status.
+	Init bool `json:"init,omitempty"`
+
+	// TimeOffsets specifies the offsets of the clocks (by name, "monotonic"
+	// or "boottime") in the container's time namespace. They can only be set
+	// when creating a new time namespace.
+	TimeOffsets map[string]specs.LinuxTimeOffset `json:"time_offsets,omitempty"`
+
+	// Personality specifies the personality to set for the container
+	// processes (both init and the exec'd ones) before they are executed.
+	Personality *LinuxPersonality `json:"personality,omitempty"`
+
+	// Scheduler specifies the scheduling policy and attributes to set for
+	// the container process right before it is executed. The exec'd
+	// processes can have their own, see libcontainer.Process.
+	Scheduler *Scheduler `json:"scheduler,omitempty"`
+
+	// IOPriority specifies the I/O scheduling class and priority to set
+	// for the container process. The exec'd processes can have their own,
+	// see libcontainer.Process.
+	IOPriority *IOPriority `json:"io_priority,omitempty"`
+
+	// CPUAffinity specifies the CPU affinity of the container process,
+	// while it is set up and once it is executed. The exec'd processes can
+	// have their own, see libcontainer.Process.
+	CPUAffinity *CPUAffinity `json:"cpu_affinity,omitempty"`
+
+	// MemoryPolicy specifies the NUMA memory policy to set for the container
+	// processes (both init and the exec'd ones) before they are executed.
+	MemoryPolicy *LinuxMemoryPolicy `json:"memory_policy,omitempty"`
+
+	// Landlock specifies a Landlock ruleset to restrict the container
+	// process with, right before it is executed. It requires NoNewPrivileges.
+	// The exec'd processes can have their own, see libcontainer.Process.
+	Landlock *Landlock `json:"landlock,omitempty"`
+
 	// IntelRdt specifies settings for Intel RDT group that the container is placed into
 	// to limit the resources (e.g., L3 cache, memory bandwidth) the container has available
 	IntelRdt *IntelRdt `json:"intel_rdt,omitempty"`
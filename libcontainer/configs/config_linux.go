@@ -1,6 +1,17 @@
 package configs
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/system"
+)
 
 var (
 	errNoUIDMap   = errors.New("User namespaces enabled, but no uid mappings found.")
@@ -66,3 +77,233 @@ func (c Config) hostIDFromMapping(containerID int, uMap []IDMap) (int, bool) {
 	}
 	return -1, false
 }
+
+var schedPolicies = map[specs.LinuxSchedulerPolicy]uint32{
+	specs.SchedOther:    0,
+	specs.SchedFIFO:     1,
+	specs.SchedRR:       2,
+	specs.SchedBatch:    3,
+	specs.SchedIdle:     5,
+	specs.SchedDeadline: 6,
+}
+
+var schedFlags = map[specs.LinuxSchedulerFlag]uint64{
+	specs.SchedFlagResetOnFork:  0x01,
+	specs.SchedFlagReclaim:      0x02,
+	specs.SchedFlagDLOverrun:    0x04,
+	specs.SchedFlagKeepPolicy:   0x08,
+	specs.SchedFlagKeepParams:   0x10,
+	specs.SchedFlagUtilClampMin: 0x20,
+	specs.SchedFlagUtilClampMax: 0x40,
+}
+
+// ToSchedAttr checks the scheduling attributes s, and converts them to the
+// argument of sched_setattr(2).
+func ToSchedAttr(s *Scheduler) (*system.SchedAttr, error) {
+	if s.Policy == "" {
+		return nil, errors.New("scheduler policy is required")
+	}
+	policy, ok := schedPolicies[s.Policy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported scheduler policy %q", s.Policy)
+	}
+	if s.Nice < -20 || s.Nice > 19 {
+		return nil, fmt.Errorf("invalid scheduler nice value %d, must be between -20 and 19", s.Nice)
+	}
+	if s.Priority < 0 || s.Priority > 99 {
+		return nil, fmt.Errorf("invalid scheduler priority %d, must be between 0 and 99", s.Priority)
+	}
+	if s.Priority != 0 && s.Policy != specs.SchedFIFO && s.Policy != specs.SchedRR {
+		return nil, fmt.Errorf("scheduler priority can only be set with the %s or %s policy", specs.SchedFIFO, specs.SchedRR)
+	}
+	if (s.Runtime != 0 || s.Deadline != 0 || s.Period != 0) && s.Policy != specs.SchedDeadline {
+		return nil, fmt.Errorf("scheduler runtime, deadline and period can only be set with the %s policy", specs.SchedDeadline)
+	}
+	var flags uint64
+	for _, f := range s.Flags {
+		flag, ok := schedFlags[f]
+		if !ok {
+			return nil, fmt.Errorf("unsupported scheduler flag %q", f)
+		}
+		flags |= flag
+	}
+	return &system.SchedAttr{
+		Policy:   policy,
+		Flags:    flags,
+		Nice:     s.Nice,
+		Priority: uint32(s.Priority),
+		Runtime:  s.Runtime,
+		Deadline: s.Deadline,
+		Period:   s.Period,
+	}, nil
+}
+
+var ioprioClasses = map[specs.IOPriorityClass]int{
+	specs.IOPRIO_CLASS_RT:   1,
+	specs.IOPRIO_CLASS_BE:   2,
+	specs.IOPRIO_CLASS_IDLE: 3,
+}
+
+// ToIOPrio checks the I/O priority p, and converts it to the argument of
+// ioprio_set(2).
+func ToIOPrio(p *IOPriority) (int, error) {
+	class, ok := ioprioClasses[p.Class]
+	if !ok {
+		return 0, fmt.Errorf("unsupported I/O priority class %q", p.Class)
+	}
+	// The idle class has no levels.
+	if p.Priority < 0 || p.Priority > 7 || (p.Class == specs.IOPRIO_CLASS_IDLE && p.Priority != 0) {
+		return 0, fmt.Errorf("invalid I/O priority level %d for class %s", p.Priority, p.Class)
+	}
+	const ioprioClassShift = 13
+	return class<<ioprioClassShift | p.Priority, nil
+}
+
+// CPUAffinity is the CPU affinity of a container process. The initial one is
+// set while runc sets the process up (in nsexec and init), so that it does
+// not disturb the other CPUs, and the final one right before the process is
+// executed. Either can be nil to leave the affinity as is.
+type CPUAffinity struct {
+	Initial *unix.CPUSet `json:"initial,omitempty"`
+	Final   *unix.CPUSet `json:"final,omitempty"`
+}
+
+// ConvertCPUAffinity converts the CPU affinity of the runtime spec, which has
+// the masks in the cpuset list format (e.g. "0-3,7").
+func ConvertCPUAffinity(sa *specs.CPUAffinity) (*CPUAffinity, error) {
+	if sa == nil || (sa.Initial == "" && sa.Final == "") {
+		return nil, nil
+	}
+	var (
+		a   CPUAffinity
+		err error
+	)
+	if sa.Initial != "" {
+		if a.Initial, err = ParseCPUSet(sa.Initial); err != nil {
+			return nil, fmt.Errorf("invalid initial CPU affinity %q: %w", sa.Initial, err)
+		}
+	}
+	if sa.Final != "" {
+		if a.Final, err = ParseCPUSet(sa.Final); err != nil {
+			return nil, fmt.Errorf("invalid final CPU affinity %q: %w", sa.Final, err)
+		}
+	}
+	return &a, nil
+}
+
+// ParseCPUSet parses a list of CPUs in the cpuset list format, as used by the
+// cpuset.cpus cgroup files (e.g. "0-3,7").
+func ParseCPUSet(str string) (*unix.CPUSet, error) {
+	return parseList(str)
+}
+
+// ParseNodeSet parses a list of memory nodes in the cpuset list format, as
+// used by the cpuset.mems cgroup files (e.g. "0,2-3"), to a node mask.
+func ParseNodeSet(str string) (*NodeSet, error) {
+	set, err := parseList(str)
+	return (*NodeSet)(set), err
+}
+
+// parseList parses a list in the cpuset list format to a bit mask.
+func parseList(str string) (*unix.CPUSet, error) {
+	var set unix.CPUSet
+	maxBit := uint64(unsafe.Sizeof(set) * 8)
+	for _, r := range strings.Split(str, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(r, "-")
+		start, err := strconv.ParseUint(first, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.ParseUint(last, 10, 32); err != nil {
+				return nil, err
+			}
+		}
+		if start > end {
+			return nil, errors.New("invalid range: " + r)
+		}
+		if end >= maxBit {
+			return nil, fmt.Errorf("%d is out of range (max %d)", end, maxBit-1)
+		}
+		for i := start; i <= end; i++ {
+			set.Set(int(i))
+		}
+	}
+	if set.Count() == 0 {
+		return nil, errors.New("empty list")
+	}
+	return &set, nil
+}
+
+// Validate checks that the CPU affinity masks are within the cpuset cpus,
+// in the cpuset list format (any CPU is allowed if it is empty).
+func (a *CPUAffinity) Validate(cpus string) error {
+	if cpus == "" {
+		return nil
+	}
+	allowed, err := ParseCPUSet(cpus)
+	if err != nil {
+		return fmt.Errorf("invalid cpuset cpus %q: %w", cpus, err)
+	}
+	for _, m := range []struct {
+		name string
+		set  *unix.CPUSet
+	}{
+		{"initial", a.Initial},
+		{"final", a.Final},
+	} {
+		if m.set == nil {
+			continue
+		}
+		for i := range m.set {
+			if m.set[i]&^allowed[i] != 0 {
+				return fmt.Errorf("%s CPU affinity is not a subset of the cpuset cpus (%s)", m.name, cpus)
+			}
+		}
+	}
+	return nil
+}
+
+// NodeSet is a mask of memory nodes, with the same layout as the node masks
+// of set_mempolicy(2).
+type NodeSet unix.CPUSet
+
+// IsSubsetOf returns whether all the nodes of s are in other.
+func (s *NodeSet) IsSubsetOf(other *NodeSet) bool {
+	for i := range s {
+		if s[i]&^other[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Memory policy modes and flags, as set with set_mempolicy(2).
+const (
+	MpolDefault = iota
+	MpolPreferred
+	MpolBind
+	MpolInterleave
+	MpolLocal
+	MpolPreferredMany
+	MpolWeightedInterleave
+
+	MpolFNumaBalancing = 1 << 13
+	MpolFRelativeNodes = 1 << 14
+	MpolFStaticNodes   = 1 << 15
+)
+
+// LinuxMemoryPolicy is the NUMA memory policy of the container processes.
+type LinuxMemoryPolicy struct {
+	// Mode is the policy mode, e.g. MpolBind.
+	Mode int `json:"mode"`
+	// Nodes is the node mask, which is nil for the modes not taking any.
+	Nodes *NodeSet `json:"nodes,omitempty"`
+	// Flags are the mode flags, e.g. MpolFStaticNodes.
+	Flags int `json:"flags,omitempty"`
+}
@@ -2,6 +2,11 @@ package configs
 
 import (
 	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/system"
 )
 
 var HookNameList = []HookName{Prestart, CreateRuntime, CreateContainer, StartContainer, Poststart, Poststop}
@@ -83,3 +88,121 @@ func TestHostRootGIDWithUSERNS(t *testing.T) {
 		t.Fatalf("expected gid 1000 with no USERNS but received %d", uid)
 	}
 }
+
+func TestToSchedAttr(t *testing.T) {
+	attr, err := ToSchedAttr(&Scheduler{
+		Policy:   specs.SchedDeadline,
+		Flags:    []specs.LinuxSchedulerFlag{specs.SchedFlagResetOnFork, specs.SchedFlagDLOverrun},
+		Runtime:  10000000,
+		Deadline: 20000000,
+		Period:   30000000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := system.SchedAttr{
+		Policy:   6,
+		Flags:    0x05,
+		Runtime:  10000000,
+		Deadline: 20000000,
+		Period:   30000000,
+	}
+	if *attr != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *attr)
+	}
+
+	attr, err = ToSchedAttr(&Scheduler{Policy: specs.SchedFIFO, Priority: 10, Nice: -5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attr.Policy != 1 || attr.Priority != 10 || attr.Nice != -5 || attr.Flags != 0 {
+		t.Fatalf("unexpected %+v", *attr)
+	}
+}
+
+func TestToIOPrio(t *testing.T) {
+	for _, tc := range []struct {
+		prio     IOPriority
+		expected int
+		isErr    bool
+	}{
+		{prio: IOPriority{Class: specs.IOPRIO_CLASS_RT, Priority: 0}, expected: 1<<13 | 0},
+		{prio: IOPriority{Class: specs.IOPRIO_CLASS_BE, Priority: 7}, expected: 2<<13 | 7},
+		{prio: IOPriority{Class: specs.IOPRIO_CLASS_IDLE}, expected: 3 << 13},
+		{prio: IOPriority{Class: specs.IOPRIO_CLASS_BE, Priority: 8}, isErr: true},
+		{prio: IOPriority{Class: specs.IOPRIO_CLASS_RT, Priority: -1}, isErr: true},
+		{prio: IOPriority{Class: specs.IOPRIO_CLASS_IDLE, Priority: 1}, isErr: true},
+		{prio: IOPriority{Class: "IOPRIO_CLASS_NONE"}, isErr: true},
+	} {
+		ioprio, err := ToIOPrio(&tc.prio)
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("%+v: expected error, got nil", tc.prio)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: %v", tc.prio, err)
+		} else if ioprio != tc.expected {
+			t.Errorf("%+v: expected %#x, got %#x", tc.prio, tc.expected, ioprio)
+		}
+	}
+}
+
+func TestParseCPUSet(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		cpus  []int
+		isErr bool
+	}{
+		{in: "0", cpus: []int{0}},
+		{in: "0-2,7", cpus: []int{0, 1, 2, 7}},
+		{in: " 3 , 5-6,", cpus: []int{3, 5, 6}},
+		{in: "1023", cpus: []int{1023}},
+		{in: "", isErr: true},
+		{in: ",", isErr: true},
+		{in: "2-1", isErr: true},
+		{in: "a", isErr: true},
+		{in: "0-", isErr: true},
+		{in: "1024", isErr: true},
+	} {
+		set, err := ParseCPUSet(tc.in)
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got nil", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		var expected unix.CPUSet
+		for _, c := range tc.cpus {
+			expected.Set(c)
+		}
+		if *set != expected {
+			t.Errorf("%q: expected CPUs %v, got %v", tc.in, tc.cpus, set)
+		}
+	}
+}
+
+func TestCPUAffinityValidate(t *testing.T) {
+	a, err := ConvertCPUAffinity(&specs.CPUAffinity{Initial: "0", Final: "2-3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cpus := range []string{"", "0-3", "0,2,3,5"} {
+		if err := a.Validate(cpus); err != nil {
+			t.Errorf("cpuset %q: unexpected error: %v", cpus, err)
+		}
+	}
+	for _, cpus := range []string{"0-2", "1-3", "bad"} {
+		if err := a.Validate(cpus); err == nil {
+			t.Errorf("cpuset %q: expected error, got nil", cpus)
+		}
+	}
+	if a, err := ConvertCPUAffinity(&specs.CPUAffinity{}); a != nil || err != nil {
+		t.Errorf("empty affinity: expected nil, got %+v, %v", a, err)
+	}
+}
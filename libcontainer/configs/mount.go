@@ -35,8 +35,30 @@ type Mount struct {
 
 	// Extensions are additional flags that are specific to runc.
 	Extensions int `json:"extensions"`
+
+	// IDMapping, if set, makes this an id-mapped bind mount, see
+	// mount_setattr(2).
+	IDMapping *MountIDMapping `json:"id_mapping,omitempty"`
+}
+
+// MountIDMapping is the id-mapping of an id-mapped mount.
+type MountIDMapping struct {
+	// UIDMappings and GIDMappings are the mappings of the user namespace
+	// the mount is mapped to. If both are empty, the mount is mapped to
+	// the user namespace of the container.
+	UIDMappings []IDMap `json:"uid_mappings,omitempty"`
+	GIDMappings []IDMap `json:"gid_mappings,omitempty"`
+
+	// Recursive applies the mapping to the submounts of a recursive bind
+	// mount as well.
+	Recursive bool `json:"recursive,omitempty"`
 }
 
 func (m *Mount) IsBind() bool {
 	return m.Flags&unix.MS_BIND != 0
 }
+
+// IsIDMapped returns whether the mount is an id-mapped mount.
+func (m *Mount) IsIDMapped() bool {
+	return m.IDMapping != nil
+}
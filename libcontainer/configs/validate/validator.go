@@ -11,6 +11,7 @@ import (
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/intelrdt"
+	"github.com/opencontainers/runc/libcontainer/landlock"
 	selinux "github.com/opencontainers/selinux/go-selinux"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
@@ -29,6 +30,14 @@ func Validate(config *configs.Config) error {
 		sysctl,
 		intelrdtCheck,
 		rootlessEUIDCheck,
+		personality,
+		idmappedMounts,
+		scheduler,
+		ioPriority,
+		cpuAffinity,
+		memoryPolicy,
+		landlockCheck,
+		procfs,
 	}
 	for _, c := range checks {
 		if err := c(config); err != nil {
@@ -79,6 +88,138 @@ func hostname(config *configs.Config) error {
 	if config.Hostname != "" && !config.Namespaces.Contains(configs.NEWUTS) {
 		return errors.New("unable to set hostname without a private UTS namespace")
 	}
+	if config.Domainname != "" && (!config.Namespaces.Contains(configs.NEWUTS) || config.Namespaces.PathOf(configs.NEWUTS) != "") {
+		return errors.New("unable to set domainname without a private UTS namespace")
+	}
+	return nil
+}
+
+func personality(config *configs.Config) error {
+	if p := config.Personality; p != nil {
+		switch p.Domain {
+		case configs.PerLinux, configs.PerLinux32:
+		default:
+			return fmt.Errorf("invalid personality domain %#x", p.Domain)
+		}
+	}
+	return nil
+}
+
+func scheduler(config *configs.Config) error {
+	if s := config.Scheduler; s != nil {
+		if _, err := configs.ToSchedAttr(s); err != nil {
+			return fmt.Errorf("invalid scheduler: %w", err)
+		}
+	}
+	return nil
+}
+
+func ioPriority(config *configs.Config) error {
+	if p := config.IOPriority; p != nil {
+		if _, err := configs.ToIOPrio(p); err != nil {
+			return fmt.Errorf("invalid I/O priority: %w", err)
+		}
+	}
+	return nil
+}
+
+func cpuAffinity(config *configs.Config) error {
+	a := config.CPUAffinity
+	if a == nil || config.Cgroups == nil || config.Cgroups.Resources == nil {
+		return nil
+	}
+	return a.Validate(config.Cgroups.Resources.CpusetCpus)
+}
+
+func memoryPolicy(config *configs.Config) error {
+	p := config.MemoryPolicy
+	if p == nil {
+		return nil
+	}
+	switch p.Mode {
+	case configs.MpolDefault, configs.MpolLocal:
+		if p.Nodes != nil || p.Flags != 0 {
+			return errors.New("invalid memory policy: nodes and flags are not allowed with the default and local modes")
+		}
+	case configs.MpolPreferred:
+		// No nodes means the local node.
+	case configs.MpolBind, configs.MpolInterleave, configs.MpolPreferredMany, configs.MpolWeightedInterleave:
+		if p.Nodes == nil {
+			return errors.New("invalid memory policy: nodes are required")
+		}
+	default:
+		return fmt.Errorf("invalid memory policy mode %d", p.Mode)
+	}
+	if p.Flags&^(configs.MpolFNumaBalancing|configs.MpolFRelativeNodes|configs.MpolFStaticNodes) != 0 {
+		return fmt.Errorf("invalid memory policy flags %#x", p.Flags)
+	}
+	if p.Flags&configs.MpolFRelativeNodes != 0 && p.Flags&configs.MpolFStaticNodes != 0 {
+		return errors.New("invalid memory policy: the static and relative nodes flags are mutually exclusive")
+	}
+	// Relative nodes are relative to the allowed ones, rather than node IDs.
+	if p.Nodes == nil || p.Flags&configs.MpolFRelativeNodes != 0 {
+		return nil
+	}
+	if config.Cgroups == nil || config.Cgroups.Resources == nil || config.Cgroups.Resources.CpusetMems == "" {
+		return nil
+	}
+	mems := config.Cgroups.Resources.CpusetMems
+	allowed, err := configs.ParseNodeSet(mems)
+	if err != nil {
+		return fmt.Errorf("invalid cpuset mems %q: %w", mems, err)
+	}
+	if !p.Nodes.IsSubsetOf(allowed) {
+		return fmt.Errorf("invalid memory policy: nodes are not a subset of the cpuset mems (%s)", mems)
+	}
+	return nil
+}
+
+func landlockCheck(config *configs.Config) error {
+	if config.Landlock == nil {
+		return nil
+	}
+	// Without no_new_privs, landlock_restrict_self(2) requires CAP_SYS_ADMIN,
+	// and the ruleset could be escaped via set-user-ID programs anyway.
+	if !config.NoNewPrivileges {
+		return errors.New("landlock requires noNewPrivileges to be set")
+	}
+	if err := landlock.Validate(config.Landlock); err != nil {
+		return fmt.Errorf("invalid landlock ruleset: %w", err)
+	}
+	return nil
+}
+
+func procfs(config *configs.Config) error {
+	p := config.Procfs
+	if p == nil {
+		return nil
+	}
+	switch p.HidePid {
+	case "", "off", "noaccess", "invisible", "ptraceable":
+	default:
+		return fmt.Errorf("invalid procfs hidepid option %q", p.HidePid)
+	}
+	hasProc := false
+	for _, m := range config.Mounts {
+		if m.Device == "proc" && filepath.Clean(m.Destination) == "/proc" {
+			hasProc = true
+		}
+	}
+	if !hasProc || !config.Namespaces.Contains(configs.NEWNS) {
+		return errors.New("procfs options require a proc mount on /proc in a private MNT namespace")
+	}
+	if !p.SubsetPid {
+		return nil
+	}
+	// With subset=pid, nothing but the process directories appear in /proc.
+	if len(config.Sysctl) > 0 {
+		return errors.New("sysctls can't be set with the procfs subset=pid option (/proc/sys is hidden)")
+	}
+	for _, m := range config.Mounts {
+		if strings.HasPrefix(filepath.Clean(m.Destination), "/proc/") {
+			return fmt.Errorf("%s can't be mounted with the procfs subset=pid option", m.Destination)
+		}
+	}
 	return nil
 }
 
@@ -112,6 +253,36 @@ func namespaces(config *configs.Config) error {
 		}
 	}
 
+	if config.Namespaces.Contains(configs.NEWTIME) {
+		if _, err := os.Stat("/proc/self/ns/time"); os.IsNotExist(err) {
+			return errors.New("time namespaces aren't enabled in the kernel")
+		}
+	}
+
+	return timeOffsets(config)
+}
+
+// timeOffsets validates the clock offsets of the time namespace.
+func timeOffsets(config *configs.Config) error {
+	if len(config.TimeOffsets) == 0 {
+		return nil
+	}
+	if !config.Namespaces.Contains(configs.NEWTIME) {
+		return errors.New("time namespace offsets specified, but time namespace isn't enabled in the config")
+	}
+	if config.Namespaces.PathOf(configs.NEWTIME) != "" {
+		return errors.New("time namespace offsets can't be set when joining an existing time namespace")
+	}
+	for clock, offset := range config.TimeOffsets {
+		switch clock {
+		case "monotonic", "boottime":
+		default:
+			return fmt.Errorf("invalid time namespace offset: unknown clock %q", clock)
+		}
+		if offset.Nanosecs >= 1e9 {
+			return fmt.Errorf("invalid time namespace offset for clock %q: nanosecs must be less than 1e9", clock)
+		}
+	}
 	return nil
 }
 
@@ -249,6 +420,10 @@ func cgroupsCheck(config *configs.Config) error {
 		return cgroups.ErrV1NoUnified
 	}
 
+	if !cgroups.IsCgroup2UnifiedMode() && len(r.IOLatencyDevice) > 0 {
+		return errors.New("io latency targets require cgroup v2")
+	}
+
 	if cgroups.IsCgroup2UnifiedMode() {
 		_, err := cgroups.ConvertMemorySwapToCgroupV2Value(r.MemorySwap, r.Memory)
 		if err != nil {
@@ -256,6 +431,38 @@ func cgroupsCheck(config *configs.Config) error {
 		}
 	}
 
+	if err := cgroups.CheckMemoryBoundaries(r.MemoryMin, r.MemoryLow, r.MemoryHigh, r.Memory); err != nil {
+		return err
+	}
+
+	return blockDevices(r)
+}
+
+// blockDevices checks that the per-device IO resources refer to block
+// devices, since writing the cgroup files would fail otherwise.
+func blockDevices(r *configs.Resources) error {
+	var devs []configs.BlockIODevice
+	for _, wd := range r.BlkioWeightDevice {
+		devs = append(devs, wd.BlockIODevice)
+	}
+	for _, tds := range [][]*configs.ThrottleDevice{
+		r.BlkioThrottleReadBpsDevice,
+		r.BlkioThrottleWriteBpsDevice,
+		r.BlkioThrottleReadIOPSDevice,
+		r.BlkioThrottleWriteIOPSDevice,
+	} {
+		for _, td := range tds {
+			devs = append(devs, td.BlockIODevice)
+		}
+	}
+	for _, ld := range r.IOLatencyDevice {
+		devs = append(devs, ld.BlockIODevice)
+	}
+	for _, d := range devs {
+		if err := cgroups.CheckBlockDevice(d.Major, d.Minor); err != nil {
+			return fmt.Errorf("invalid blkio device: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -269,6 +476,51 @@ func mounts(config *configs.Config) error {
 	return nil
 }
 
+func idmappedMounts(config *configs.Config) error {
+	for _, m := range config.Mounts {
+		if !m.IsIDMapped() {
+			continue
+		}
+		if err := checkIDMappedMount(config, m); err != nil {
+			return fmt.Errorf("invalid id-mapped mount %s: %w", m.Destination, err)
+		}
+	}
+	return nil
+}
+
+// checkIDMappedMount checks the configuration of the id-mapped mount m.
+// Whether the kernel and the filesystem of its source support id-mapped
+// mounts is only found out when the container is created.
+func checkIDMappedMount(config *configs.Config, m *configs.Mount) error {
+	if !m.IsBind() {
+		return errors.New("only bind mounts can be id-mapped")
+	}
+	if !config.Namespaces.Contains(configs.NEWNS) {
+		return errors.New("id-mapped mounts require a private MNT namespace")
+	}
+	// nsexec.c send_idmapsources() requires CAP_SYS_ADMIN in the user
+	// namespace the mount is mapped to.
+	if config.RootlessEUID {
+		return errors.New("id-mapped mounts are not supported for rootless containers")
+	}
+
+	uidMap, gidMap := m.IDMapping.UIDMappings, m.IDMapping.GIDMappings
+	if len(uidMap) == 0 && len(gidMap) == 0 {
+		// The mount is mapped to the user namespace of the container.
+		if !config.Namespaces.Contains(configs.NEWUSER) {
+			return errors.New("id-mapped mounts without mappings require a USER namespace")
+		}
+		if config.Namespaces.PathOf(configs.NEWUSER) != "" {
+			return nil
+		}
+		uidMap, gidMap = config.UidMappings, config.GidMappings
+	}
+	if len(uidMap) == 0 || len(gidMap) == 0 {
+		return errors.New("both uid and gid mappings are required")
+	}
+	return nil
+}
+
 func isHostNetNS(path string) (bool, error) {
 	const currentProcessNetns = "/proc/self/ns/net"
 
@@ -1,11 +1,14 @@
 package validate
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/sys/unix"
 )
 
@@ -94,6 +97,347 @@ func TestValidateHostnameWithoutUTSNamespace(t *testing.T) {
 	}
 }
 
+func TestValidateDomainname(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		ns    *configs.Namespace
+		isErr bool
+	}{
+		{name: "new uts", ns: &configs.Namespace{Type: configs.NEWUTS}},
+		{name: "no uts", isErr: true},
+		{name: "joined uts", ns: &configs.Namespace{Type: configs.NEWUTS, Path: "/proc/1/ns/uts"}, isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Domainname: "example.org",
+		}
+		if tc.ns != nil {
+			config.Namespaces = configs.Namespaces{*tc.ns}
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%s: expected error, got nil", tc.name)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
+func TestValidatePersonality(t *testing.T) {
+	for _, tc := range []struct {
+		domain int
+		isErr  bool
+	}{
+		{domain: configs.PerLinux},
+		{domain: configs.PerLinux32},
+		{domain: 0x0001, isErr: true},
+		{domain: -1, isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs:      "/var",
+			Personality: &configs.LinuxPersonality{Domain: tc.domain},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("domain %#x: expected error, got nil", tc.domain)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("domain %#x: unexpected error: %v", tc.domain, err)
+		}
+	}
+}
+
+func TestValidateScheduler(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		scheduler configs.Scheduler
+		isErr     bool
+	}{
+		{name: "fifo", scheduler: configs.Scheduler{Policy: specs.SchedFIFO, Priority: 50}},
+		{name: "batch", scheduler: configs.Scheduler{Policy: specs.SchedBatch, Nice: 10, Flags: []specs.LinuxSchedulerFlag{specs.SchedFlagResetOnFork}}},
+		{name: "deadline", scheduler: configs.Scheduler{Policy: specs.SchedDeadline, Runtime: 1000000, Deadline: 2000000, Period: 2000000}},
+		{name: "no policy", scheduler: configs.Scheduler{Nice: 1}, isErr: true},
+		{name: "unknown policy", scheduler: configs.Scheduler{Policy: "SCHED_FOO"}, isErr: true},
+		{name: "iso policy", scheduler: configs.Scheduler{Policy: specs.SchedISO}, isErr: true},
+		{name: "nice out of range", scheduler: configs.Scheduler{Policy: specs.SchedOther, Nice: 20}, isErr: true},
+		{name: "priority out of range", scheduler: configs.Scheduler{Policy: specs.SchedRR, Priority: 100}, isErr: true},
+		{name: "priority without rt policy", scheduler: configs.Scheduler{Policy: specs.SchedOther, Priority: 1}, isErr: true},
+		{name: "runtime without deadline policy", scheduler: configs.Scheduler{Policy: specs.SchedFIFO, Priority: 1, Runtime: 1000000}, isErr: true},
+		{name: "unknown flag", scheduler: configs.Scheduler{Policy: specs.SchedOther, Flags: []specs.LinuxSchedulerFlag{"SCHED_FLAG_FOO"}}, isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs:    "/var",
+			Scheduler: &tc.scheduler,
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%s: expected error, got nil", tc.name)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
+func TestValidateCPUAffinity(t *testing.T) {
+	for _, tc := range []struct {
+		cpus, initial, final string
+		isErr                bool
+	}{
+		{initial: "0-3", final: "5"},
+		{cpus: "0-3", initial: "0-3", final: "1"},
+		{cpus: "0-3", initial: "2-4", isErr: true},
+		{cpus: "1", final: "0", isErr: true},
+	} {
+		a, err := configs.ConvertCPUAffinity(&specs.CPUAffinity{Initial: tc.initial, Final: tc.final})
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := &configs.Config{
+			Rootfs:      "/var",
+			CPUAffinity: a,
+			Cgroups: &configs.Cgroup{
+				Resources: &configs.Resources{CpusetCpus: tc.cpus},
+			},
+		}
+		err = Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc, err)
+		}
+	}
+}
+
+func TestValidateMemoryPolicy(t *testing.T) {
+	nodes := func(s string) *configs.NodeSet {
+		n, err := configs.ParseNodeSet(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	for _, tc := range []struct {
+		name   string
+		policy configs.LinuxMemoryPolicy
+		mems   string
+		isErr  bool
+	}{
+		{name: "default", policy: configs.LinuxMemoryPolicy{Mode: configs.MpolDefault}},
+		{name: "preferred local", policy: configs.LinuxMemoryPolicy{Mode: configs.MpolPreferred}},
+		{name: "bind", policy: configs.LinuxMemoryPolicy{Mode: configs.MpolBind, Nodes: nodes("0-1"), Flags: configs.MpolFStaticNodes}, mems: "0-3"},
+		{name: "relative", policy: configs.LinuxMemoryPolicy{Mode: configs.MpolInterleave, Nodes: nodes("0-1"), Flags: configs.MpolFRelativeNodes}, mems: "2-3"},
+		{name: "default with nodes", policy: configs.LinuxMemoryPolicy{Mode: configs.MpolDefault, Nodes: nodes("0")}, isErr: true},
+		{name: "local with flags", policy: configs.LinuxMemoryPolicy{Mode: configs.MpolLocal, Flags: configs.MpolFStaticNodes}, isErr: true},
+		{name: "bind without nodes", policy: configs.LinuxMemoryPolicy{Mode: configs.MpolBind}, isErr: true},
+		{name: "unknown mode", policy: configs.LinuxMemoryPolicy{Mode: 42, Nodes: nodes("0")}, isErr: true},
+		{name: "unknown flag", policy: configs.LinuxMemoryPolicy{Mode: configs.MpolBind, Nodes: nodes("0"), Flags: 1}, isErr: true},
+		{name: "static and relative", policy: configs.LinuxMemoryPolicy{Mode: configs.MpolBind, Nodes: nodes("0"), Flags: configs.MpolFStaticNodes | configs.MpolFRelativeNodes}, isErr: true},
+		{name: "outside of cpuset", policy: configs.LinuxMemoryPolicy{Mode: configs.MpolBind, Nodes: nodes("1-2")}, mems: "0-1", isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs:       "/var",
+			MemoryPolicy: &tc.policy,
+			Cgroups: &configs.Cgroup{
+				Resources: &configs.Resources{CpusetMems: tc.mems},
+			},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%s: expected error, got nil", tc.name)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
+func TestValidateMemoryBoundaries(t *testing.T) {
+	ptr := func(v int64) *int64 { return &v }
+	for _, tc := range []struct {
+		name  string
+		r     configs.Resources
+		isErr bool
+	}{
+		{name: "none", r: configs.Resources{}},
+		{name: "all", r: configs.Resources{MemoryMin: ptr(1), MemoryLow: ptr(2), MemoryHigh: ptr(3), Memory: 4}},
+		{name: "equal", r: configs.Resources{MemoryMin: ptr(2), MemoryLow: ptr(2), MemoryHigh: ptr(2), Memory: 2}},
+		{name: "some", r: configs.Resources{MemoryMin: ptr(1), Memory: 4}},
+		{name: "max", r: configs.Resources{MemoryLow: ptr(2), MemoryHigh: ptr(-1), Memory: -1}},
+		{name: "cleared", r: configs.Resources{MemoryMin: ptr(0), MemoryLow: ptr(0), Memory: 4}},
+		{name: "min above low", r: configs.Resources{MemoryMin: ptr(3), MemoryLow: ptr(2)}, isErr: true},
+		{name: "low above max", r: configs.Resources{MemoryLow: ptr(5), Memory: 4}, isErr: true},
+		{name: "high above max", r: configs.Resources{MemoryHigh: ptr(5), Memory: 4}, isErr: true},
+		{name: "high max above max", r: configs.Resources{MemoryHigh: ptr(-1), Memory: 4}, isErr: true},
+		{name: "negative", r: configs.Resources{MemoryLow: ptr(-2)}, isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs: "/var",
+			Cgroups: &configs.Cgroup{
+				Resources: &tc.r,
+			},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%s: expected error, got nil", tc.name)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
+func TestValidateBlockDevices(t *testing.T) {
+	config := &configs.Config{
+		Rootfs: "/var",
+		Cgroups: &configs.Cgroup{
+			Resources: &configs.Resources{
+				// There is no block device with major number 0.
+				BlkioThrottleReadBpsDevice: []*configs.ThrottleDevice{configs.NewThrottleDevice(0, 0, 1024)},
+			},
+		},
+	}
+	if err := Validate(config); err == nil {
+		t.Error("expected error for a non-existent block device, got nil")
+	}
+
+	devs, _ := os.ReadDir("/sys/dev/block")
+	if len(devs) == 0 {
+		t.Skip("no block devices")
+	}
+	var major, minor int64
+	if _, err := fmt.Sscanf(devs[0].Name(), "%d:%d", &major, &minor); err != nil {
+		t.Fatal(err)
+	}
+	config.Cgroups.Resources.BlkioThrottleReadBpsDevice = []*configs.ThrottleDevice{configs.NewThrottleDevice(major, minor, 1024)}
+	config.Cgroups.Resources.BlkioWeightDevice = []*configs.WeightDevice{configs.NewWeightDevice(major, minor, 500, 0)}
+	if err := Validate(config); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateLandlock(t *testing.T) {
+	rule := func(access string, paths ...string) configs.LandlockRule {
+		return configs.LandlockRule{AllowedAccess: strings.Split(access, ","), Paths: paths}
+	}
+	for _, tc := range []struct {
+		name     string
+		landlock configs.Landlock
+		noNNP    bool
+		isErr    bool
+	}{
+		{name: "all handled", landlock: configs.Landlock{Rules: []configs.LandlockRule{rule("read_file,execute", "/bin", "/lib")}}},
+		{name: "some handled", landlock: configs.Landlock{HandledAccessFS: []string{"write_file", "make_reg"}, Rules: []configs.LandlockRule{rule("write_file", "/tmp")}}},
+		{name: "deny all", landlock: configs.Landlock{HandledAccessFS: []string{"write_file"}}},
+		{name: "without no_new_privs", landlock: configs.Landlock{}, noNNP: true, isErr: true},
+		{name: "unknown handled right", landlock: configs.Landlock{HandledAccessFS: []string{"write"}}, isErr: true},
+		{name: "unknown allowed right", landlock: configs.Landlock{Rules: []configs.LandlockRule{rule("read", "/")}}, isErr: true},
+		{name: "not handled", landlock: configs.Landlock{HandledAccessFS: []string{"write_file"}, Rules: []configs.LandlockRule{rule("read_file", "/")}}, isErr: true},
+		{name: "no paths", landlock: configs.Landlock{Rules: []configs.LandlockRule{rule("read_file")}}, isErr: true},
+		{name: "relative path", landlock: configs.Landlock{Rules: []configs.LandlockRule{rule("read_file", "bin")}}, isErr: true},
+	} {
+		config := &configs.Config{
+			Rootfs:          "/var",
+			NoNewPrivileges: !tc.noNNP,
+			Landlock:        &tc.landlock,
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%s: expected error, got nil", tc.name)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
+func TestValidateProcfs(t *testing.T) {
+	proc := &configs.Mount{Device: "proc", Source: "proc", Destination: "/proc"}
+	for _, tc := range []struct {
+		name   string
+		procfs configs.Procfs
+		mounts []*configs.Mount
+		sysctl map[string]string
+		isErr  bool
+	}{
+		{name: "subset", procfs: configs.Procfs{SubsetPid: true, HidePid: "invisible"}, mounts: []*configs.Mount{proc}},
+		{name: "hidepid", procfs: configs.Procfs{HidePid: "ptraceable"}, mounts: []*configs.Mount{proc}, sysctl: map[string]string{"kernel.shmmax": "1"}},
+		{name: "invalid hidepid", procfs: configs.Procfs{HidePid: "2"}, mounts: []*configs.Mount{proc}, isErr: true},
+		{name: "no proc mount", procfs: configs.Procfs{SubsetPid: true}, isErr: true},
+		{name: "sysctl", procfs: configs.Procfs{SubsetPid: true}, mounts: []*configs.Mount{proc}, sysctl: map[string]string{"kernel.shmmax": "1"}, isErr: true},
+		{
+			name:   "mount inside proc",
+			procfs: configs.Procfs{SubsetPid: true},
+			mounts: []*configs.Mount{proc, {Device: "bind", Source: "/var/lib/lxcfs/proc/meminfo", Destination: "/proc/meminfo"}},
+			isErr:  true,
+		},
+	} {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces{{Type: configs.NEWNS}, {Type: configs.NEWIPC}},
+			Mounts:     tc.mounts,
+			Sysctl:     tc.sysctl,
+			Procfs:     &tc.procfs,
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%s: expected error, got nil", tc.name)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
+func TestValidateTimeOffsets(t *testing.T) {
+	if _, err := os.Stat("/proc/self/ns/time"); err != nil {
+		t.Skip("Test requires timens.")
+	}
+	for _, tc := range []struct {
+		name    string
+		ns      *configs.Namespace
+		offsets map[string]specs.LinuxTimeOffset
+		isErr   bool
+	}{
+		{
+			name:    "valid",
+			ns:      &configs.Namespace{Type: configs.NEWTIME},
+			offsets: map[string]specs.LinuxTimeOffset{"monotonic": {Secs: 10}, "boottime": {Secs: -5, Nanosecs: 100}},
+		},
+		{
+			name:    "without time namespace",
+			offsets: map[string]specs.LinuxTimeOffset{"boottime": {Secs: 10}},
+			isErr:   true,
+		},
+		{
+			name:    "joining a time namespace",
+			ns:      &configs.Namespace{Type: configs.NEWTIME, Path: "/proc/1/ns/time"},
+			offsets: map[string]specs.LinuxTimeOffset{"boottime": {Secs: 10}},
+			isErr:   true,
+		},
+		{
+			name:    "unknown clock",
+			ns:      &configs.Namespace{Type: configs.NEWTIME},
+			offsets: map[string]specs.LinuxTimeOffset{"realtime": {Secs: 10}},
+			isErr:   true,
+		},
+		{
+			name:    "invalid nanosecs",
+			ns:      &configs.Namespace{Type: configs.NEWTIME},
+			offsets: map[string]specs.LinuxTimeOffset{"monotonic": {Nanosecs: 1e9}},
+			isErr:   true,
+		},
+	} {
+		config := &configs.Config{
+			Rootfs:      "/var",
+			TimeOffsets: tc.offsets,
+		}
+		if tc.ns != nil {
+			config.Namespaces = configs.Namespaces{*tc.ns}
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%s: expected error, got nil", tc.name)
+		} else if !tc.isErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
 func TestValidateSecurityWithMaskPaths(t *testing.T) {
 	config := &configs.Config{
 		Rootfs:    "/var",
@@ -359,3 +703,108 @@ func TestValidateMounts(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateIDMappedMounts(t *testing.T) {
+	source := t.TempDir()
+	mapping := []configs.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	userns := configs.Namespaces{{Type: configs.NEWNS}, {Type: configs.NEWUSER}}
+
+	testCases := []struct {
+		name       string
+		namespaces configs.Namespaces
+		rootless   bool
+		mount      *configs.Mount
+		isErr      bool
+	}{
+		{
+			name:       "container userns",
+			namespaces: userns,
+			mount: &configs.Mount{
+				Source:    source,
+				Device:    "bind",
+				Flags:     unix.MS_BIND,
+				IDMapping: &configs.MountIDMapping{},
+			},
+		},
+		{
+			name:       "own mappings",
+			namespaces: configs.Namespaces{{Type: configs.NEWNS}},
+			mount: &configs.Mount{
+				Source: source,
+				Device: "bind",
+				Flags:  unix.MS_BIND | unix.MS_REC,
+				IDMapping: &configs.MountIDMapping{
+					UIDMappings: mapping,
+					GIDMappings: mapping,
+					Recursive:   true,
+				},
+			},
+		},
+		{
+			name:       "not a bind mount",
+			namespaces: userns,
+			mount: &configs.Mount{
+				Source:    "tmpfs",
+				Device:    "tmpfs",
+				IDMapping: &configs.MountIDMapping{},
+			},
+			isErr: true,
+		},
+		{
+			name:       "without userns",
+			namespaces: configs.Namespaces{{Type: configs.NEWNS}},
+			mount: &configs.Mount{
+				Source:    source,
+				Device:    "bind",
+				Flags:     unix.MS_BIND,
+				IDMapping: &configs.MountIDMapping{},
+			},
+			isErr: true,
+		},
+		{
+			name:       "only uid mappings",
+			namespaces: userns,
+			mount: &configs.Mount{
+				Source:    source,
+				Device:    "bind",
+				Flags:     unix.MS_BIND,
+				IDMapping: &configs.MountIDMapping{UIDMappings: mapping},
+			},
+			isErr: true,
+		},
+		{
+			name:       "rootless",
+			namespaces: userns,
+			rootless:   true,
+			mount: &configs.Mount{
+				Source:    source,
+				Device:    "bind",
+				Flags:     unix.MS_BIND,
+				IDMapping: &configs.MountIDMapping{},
+			},
+			isErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc.mount.Destination = "/mnt"
+		config := &configs.Config{
+			Rootfs:       "/var",
+			Namespaces:   tc.namespaces,
+			RootlessEUID: tc.rootless,
+			Mounts:       []*configs.Mount{tc.mount},
+		}
+		if tc.namespaces.Contains(configs.NEWUSER) {
+			config.UidMappings = mapping
+			config.GidMappings = mapping
+		}
+
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%s: expected error, got nil", tc.name)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
@@ -39,6 +39,33 @@ func (s Status) String() string {
 	}
 }
 
+// StopStep is the step of Container.Stop which has ended the container.
+type StopStep int
+
+const (
+	// StopNotRunning denotes that the container was not running.
+	StopNotRunning StopStep = iota
+	// StopSignaled denotes that the init process has exited after
+	// receiving the stop signal.
+	StopSignaled
+	// StopKilled denotes that all the container processes had to be
+	// killed after the stop timeout.
+	StopKilled
+)
+
+func (s StopStep) String() string {
+	switch s {
+	case StopNotRunning:
+		return "not running"
+	case StopSignaled:
+		return "signaled"
+	case StopKilled:
+		return "killed"
+	default:
+		return "unknown"
+	}
+}
+
 // BaseState represents the platform agnostic pieces relating to a
 // running container's state
 type BaseState struct {
@@ -56,4 +83,77 @@ type BaseState struct {
 
 	// Config is the container's configuration.
 	Config configs.Config `json:"config"`
+
+	// ExitStatus describes how the init process exited. It is only set
+	// once the container has stopped, and only if whoever reaped the init
+	// process has recorded its exit.
+	ExitStatus *ExitStatus `json:"exit_status,omitempty"`
+}
+
+// ExitStatus represents the way the container's init process has exited
+// (or, in an exec-exited lifecycle event, the way an exec'd process has).
+type ExitStatus struct {
+	// ExitCode is the exit code of the init process. If the process was
+	// killed by a signal, it is 128 plus the signal number, the same as
+	// reported by a shell.
+	ExitCode int `json:"exit_code"`
+
+	// Signal is the number of the signal which terminated the init process,
+	// or 0 if it exited normally.
+	Signal int `json:"signal,omitempty"`
+
+	// OOMKilled is set if the kernel OOM killer has killed any process in the
+	// container's cgroup.
+	OOMKilled bool `json:"oom_killed,omitempty"`
+
+	// FinishedAt is the time the exit of the init process was recorded, in UTC.
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// LifecycleEventType is the type of a LifecycleEvent.
+type LifecycleEventType string
+
+const (
+	// EventCreated is recorded once the container has been created.
+	EventCreated LifecycleEventType = "created"
+	// EventStarted is recorded once the user process of a created
+	// container has been started (or once a container has been restored).
+	EventStarted LifecycleEventType = "started"
+	// EventPaused is recorded once the container has been paused.
+	EventPaused LifecycleEventType = "paused"
+	// EventResumed is recorded once the container has been resumed.
+	EventResumed LifecycleEventType = "resumed"
+	// EventExecStarted is recorded once an additional process has been
+	// started in the container.
+	EventExecStarted LifecycleEventType = "exec-started"
+	// EventExecExited is recorded once an additional process has exited,
+	// if whoever reaped it has recorded its exit.
+	EventExecExited LifecycleEventType = "exec-exited"
+	// EventStopped is recorded once the init process has exited, along with
+	// its exit status if it is known. Unless a runc process waits for init,
+	// its exit is only recorded once it is seen (e.g. by runc state, or while
+	// following the events).
+	EventStopped LifecycleEventType = "stopped"
+	// EventDeleted is recorded when the container is destroyed. It is the
+	// last event of a container.
+	EventDeleted LifecycleEventType = "deleted"
+)
+
+// LifecycleEvent is a transition in the lifecycle of a container, or of a
+// process executed in it, as recorded in the container state directory.
+type LifecycleEvent struct {
+	// Seq is the sequence number of the event. The events of a container
+	// are numbered from 1, without gaps.
+	Seq uint64 `json:"seq"`
+
+	// Timestamp is the time the event was recorded, in UTC.
+	Timestamp time.Time `json:"timestamp"`
+
+	Type LifecycleEventType `json:"type"`
+
+	// Pid is the pid of the process the event is about, if any.
+	Pid int `json:"pid,omitempty"`
+
+	// ExitStatus is set for the stopped (if known) and exec-exited events.
+	ExitStatus *ExitStatus `json:"exit_status,omitempty"`
 }
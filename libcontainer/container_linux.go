@@ -2,6 +2,7 @@ package libcontainer
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -31,6 +33,7 @@ import (
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/intelrdt"
 	"github.com/opencontainers/runc/libcontainer/system"
+	"github.com/opencontainers/runc/libcontainer/userns"
 	"github.com/opencontainers/runc/libcontainer/utils"
 )
 
@@ -136,6 +139,50 @@ func (c *Container) Processes() ([]int, error) {
 	return pids, nil
 }
 
+// ListProcesses returns information about all the processes inside the
+// container, read from /proc, sorted by pid. The processes which exit while
+// they are listed are omitted.
+func (c *Container) ListProcesses() ([]ProcessInfo, error) {
+	status, err := c.currentStatus()
+	if err != nil {
+		return nil, err
+	}
+	// for systemd cgroup, the unit's cgroup path will be auto removed if container's all processes exited
+	if status == Stopped && !c.cgroupManager.Exists() {
+		return nil, nil
+	}
+
+	// This is the cgroup hierarchy used by GetAllPids.
+	path := c.cgroupManager.Path("")
+	if !cgroups.IsCgroup2UnifiedMode() {
+		path = c.cgroupManager.Path("devices")
+	}
+	byCgroup, err := cgroups.GetPidsByCgroup(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get all container pids: %w", err)
+	}
+	var (
+		procs []ProcessInfo
+		names = make(map[int]string)
+	)
+	for cg, pids := range byCgroup {
+		for _, pid := range pids {
+			p, err := readProcessInfo(pid)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) || errors.Is(err, unix.ESRCH) {
+					continue
+				}
+				return nil, fmt.Errorf("unable to read process %d: %w", pid, err)
+			}
+			p.User = lookupUserName(names, p.UID)
+			p.Cgroup = cg
+			procs = append(procs, *p)
+		}
+	}
+	sort.Slice(procs, func(i, j int) bool { return procs[i].Pid < procs[j].Pid })
+	return procs, nil
+}
+
 // Stats returns statistics for the container.
 func (c *Container) Stats() (*Stats, error) {
 	var (
@@ -243,6 +290,16 @@ func (c *Container) Exec() error {
 }
 
 func (c *Container) exec() error {
+	if err := c.awaitExecFifo(); err != nil {
+		return err
+	}
+	c.recordEvent(EventStarted, c.initProcess.pid(), nil)
+	return nil
+}
+
+// awaitExecFifo waits for the init process to exec the user process (see
+// Exec), or to exit.
+func (c *Container) awaitExecFifo() error {
 	path := filepath.Join(c.root, execFifoFilename)
 	pid := c.initProcess.pid()
 	blockingFifoOpenCh := awaitFifoOpen(path)
@@ -335,6 +392,9 @@ func (c *Container) start(process *Process) (retErr error) {
 	if err := parent.start(); err != nil {
 		return fmt.Errorf("unable to start container process: %w", err)
 	}
+	if !process.Init {
+		c.recordEvent(EventExecStarted, parent.pid(), nil)
+	}
 
 	if process.Init {
 		c.fifo.Close()
@@ -372,6 +432,9 @@ func (c *Container) Signal(s os.Signal, all bool) error {
 	// to avoid a PID reuse attack
 	if status == Running || status == Created || status == Paused {
 		if err := c.initProcess.signal(s); err != nil {
+			if errors.Is(err, os.ErrProcessDone) {
+				return ErrNotRunning
+			}
 			return fmt.Errorf("unable to signal init: %w", err)
 		}
 		if status == Paused {
@@ -387,6 +450,98 @@ func (c *Container) Signal(s os.Signal, all bool) error {
 	return ErrNotRunning
 }
 
+// stopKillTimeout is how long Stop waits for the init process to exit after
+// all the container processes are killed.
+const stopKillTimeout = 10 * time.Second
+
+// Stop sends sig to the container's init process, and waits for at most
+// timeout for it to exit. If it is still running after that, all the
+// container processes are killed with SIGKILL. A paused container is resumed
+// so that it can handle the signal.
+//
+// It returns the step which has ended the container, or an error if the
+// container is still running after being killed, or if ctx is done before
+// the container has stopped.
+func (c *Container) Stop(ctx context.Context, sig os.Signal, timeout time.Duration) (StopStep, error) {
+	s, ok := sig.(unix.Signal)
+	if !ok {
+		return StopNotRunning, errors.New("unsupported signal type")
+	}
+	h, err := c.signalInit(s)
+	if err != nil {
+		if errors.Is(err, ErrNotRunning) {
+			return StopNotRunning, nil
+		}
+		return StopNotRunning, err
+	}
+	defer h.close()
+	exited := func() bool {
+		c.m.Lock()
+		defer c.m.Unlock()
+		return c.runType() == Stopped
+	}
+
+	done, err := waitExit(ctx, h.pidfd, exited, timeout)
+	if err != nil {
+		return StopNotRunning, err
+	}
+	if done {
+		return StopSignaled, nil
+	}
+
+	logrus.Debugf("container %s did not stop after %s, killing it", c.id, timeout)
+	if err := c.Signal(unix.SIGKILL, true); err != nil {
+		return StopNotRunning, fmt.Errorf("unable to kill container: %w", err)
+	}
+	done, err = waitExit(ctx, h.pidfd, exited, stopKillTimeout)
+	if err != nil {
+		return StopNotRunning, err
+	}
+	if !done {
+		return StopNotRunning, errors.New("container init still running after SIGKILL")
+	}
+	return StopKilled, nil
+}
+
+// signalInit sends s to the init process, resuming the container if it is
+// paused. It returns a handle to the init process, which must be closed by
+// the caller.
+func (c *Container) signalInit(s unix.Signal) (*pidHandle, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	status, err := c.currentStatus()
+	if err != nil {
+		return nil, err
+	}
+	if status == Stopped {
+		return nil, ErrNotRunning
+	}
+	h, err := openPidHandle(c.initProcess.pid(), c.initProcessStartTime)
+	if err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return nil, ErrNotRunning
+		}
+		return nil, err
+	}
+	if err := h.signal(s); err != nil {
+		h.close()
+		if errors.Is(err, os.ErrProcessDone) {
+			return nil, ErrNotRunning
+		}
+		return nil, fmt.Errorf("unable to signal init: %w", err)
+	}
+	if status == Paused {
+		if err := c.cgroupManager.Freeze(configs.Thawed); err != nil {
+			logrus.Warnf("unable to resume container: %v", err)
+		} else if err := c.state.transition(&runningState{c: c}); err != nil {
+			logrus.Warnf("unable to resume container: %v", err)
+		} else {
+			c.recordEvent(EventResumed, 0, nil)
+		}
+	}
+	return h, nil
+}
+
 func (c *Container) createExecFifo() error {
 	rootuid, err := c.Config().HostRootUID()
 	if err != nil {
@@ -518,9 +673,10 @@ func (c *Container) shouldSendMountSources() bool {
 		return false
 	}
 
-	// We need to send sources if there are bind-mounts.
+	// We need to send sources if there are bind-mounts. The id-mapped
+	// ones are created by nsexec.c send_idmapsources() instead.
 	for _, m := range c.config.Mounts {
-		if m.IsBind() {
+		if m.IsBind() && !m.IsIDMapped() {
 			return true
 		}
 	}
@@ -528,6 +684,46 @@ func (c *Container) shouldSendMountSources() bool {
 	return false
 }
 
+// hasIDMappedMounts says whether the container has id-mapped mounts, which
+// nsexec creates outside the container user namespace.
+func (c *Container) hasIDMappedMounts() bool {
+	for _, m := range c.config.Mounts {
+		if m.IsIDMapped() {
+			return true
+		}
+	}
+	return false
+}
+
+// newIDMapUserns creates the user namespaces of the id-mapped mounts which
+// have their own mappings, and passes them to cmd as extra files. It returns
+// the paths nsexec opens them with, paired with the mounts (empty for the
+// other mounts), and the files to close once cmd is started.
+func (c *Container) newIDMapUserns(cmd *exec.Cmd) (_ []string, _ []*os.File, retErr error) {
+	var files []*os.File
+	defer func() {
+		if retErr != nil {
+			for _, f := range files {
+				_ = f.Close()
+			}
+		}
+	}()
+	paths := make([]string, len(c.config.Mounts))
+	for i, m := range c.config.Mounts {
+		if !m.IsIDMapped() || (len(m.IDMapping.UIDMappings) == 0 && len(m.IDMapping.GIDMappings) == 0) {
+			continue
+		}
+		f, err := userns.NewUserNamespace(m.IDMapping.UIDMappings, m.IDMapping.GIDMappings)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to create the user namespace of id-mapped mount %s: %w", m.Destination, err)
+		}
+		files = append(files, f)
+		cmd.ExtraFiles = append(cmd.ExtraFiles, f)
+		paths[i] = "/proc/self/fd/" + strconv.Itoa(stdioFdCount+len(cmd.ExtraFiles)-1)
+	}
+	return paths, files, nil
+}
+
 func (c *Container) newInitProcess(p *Process, cmd *exec.Cmd, messageSockPair, logFilePair filePair) (*initProcess, error) {
 	cmd.Env = append(cmd.Env, "_LIBCONTAINER_INITTYPE="+string(initStandard))
 	nsMaps := make(map[configs.NamespaceType]string)
@@ -537,8 +733,25 @@ func (c *Container) newInitProcess(p *Process, cmd *exec.Cmd, messageSockPair, l
 		}
 	}
 	_, sharePidns := nsMaps[configs.NEWPID]
-	data, err := c.bootstrapData(c.config.Namespaces.CloneFlags(), nsMaps, initStandard)
+	var (
+		idmapUserns      []string
+		idmapUsernsFiles []*os.File
+	)
+	if c.hasIDMappedMounts() {
+		if err := checkIDMappedMountSupport(); err != nil {
+			return nil, err
+		}
+		var err error
+		idmapUserns, idmapUsernsFiles, err = c.newIDMapUserns(cmd)
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := c.bootstrapData(c.config.Namespaces.CloneFlags(), nsMaps, initStandard, idmapUserns)
 	if err != nil {
+		for _, f := range idmapUsernsFiles {
+			_ = f.Close()
+		}
 		return nil, err
 	}
 
@@ -547,8 +760,8 @@ func (c *Container) newInitProcess(p *Process, cmd *exec.Cmd, messageSockPair, l
 		// prepareRootfs()). This slice MUST have the same size as c.config.Mounts.
 		mountFds := make([]int, len(c.config.Mounts))
 		for i, m := range c.config.Mounts {
-			if !m.IsBind() {
-				// Non bind-mounts do not use an fd.
+			if !m.IsBind() || m.IsIDMapped() {
+				// Non bind-mounts and id-mapped mounts do not use an fd.
 				mountFds[i] = -1
 				continue
 			}
@@ -571,6 +784,28 @@ func (c *Container) newInitProcess(p *Process, cmd *exec.Cmd, messageSockPair, l
 		)
 	}
 
+	if c.hasIDMappedMounts() {
+		// Same as the mount fds above, for the id-mapped mounts.
+		idmapFds := make([]int, len(c.config.Mounts))
+		for i, m := range c.config.Mounts {
+			if !m.IsIDMapped() {
+				idmapFds[i] = -1
+				continue
+			}
+			cmd.ExtraFiles = append(cmd.ExtraFiles, messageSockPair.child)
+			idmapFds[i] = stdioFdCount + len(cmd.ExtraFiles) - 1
+		}
+
+		idmapFdsJSON, err := json.Marshal(idmapFds)
+		if err != nil {
+			return nil, fmt.Errorf("Error creating _LIBCONTAINER_IDMAP_FDS: %w", err)
+		}
+
+		cmd.Env = append(cmd.Env,
+			"_LIBCONTAINER_IDMAP_FDS="+string(idmapFdsJSON),
+		)
+	}
+
 	init := &initProcess{
 		cmd:             cmd,
 		messageSockPair: messageSockPair,
@@ -582,6 +817,7 @@ func (c *Container) newInitProcess(p *Process, cmd *exec.Cmd, messageSockPair, l
 		process:         p,
 		bootstrapData:   data,
 		sharePidns:      sharePidns,
+		idmapUserns:     idmapUsernsFiles,
 	}
 	c.initProcess = init
 	return init, nil
@@ -595,10 +831,16 @@ func (c *Container) newSetnsProcess(p *Process, cmd *exec.Cmd, messageSockPair,
 	}
 	// for setns process, we don't have to set cloneflags as the process namespaces
 	// will only be set via setns syscall
-	data, err := c.bootstrapData(0, state.NamespacePaths, initSetns)
+	data, err := c.bootstrapData(0, state.NamespacePaths, initSetns, nil)
 	if err != nil {
 		return nil, err
 	}
+	// The container's cpuset may have been updated since it was created.
+	if p.CPUAffinity != nil && c.config.Cgroups.Resources != nil {
+		if err := p.CPUAffinity.Validate(c.config.Cgroups.Resources.CpusetCpus); err != nil {
+			return nil, err
+		}
+	}
 	proc := &setnsProcess{
 		cmd:             cmd,
 		cgroupPaths:     state.CgroupPaths,
@@ -611,6 +853,7 @@ func (c *Container) newSetnsProcess(p *Process, cmd *exec.Cmd, messageSockPair,
 		process:         p,
 		bootstrapData:   data,
 		initProcessPid:  state.InitProcessPid,
+		container:       c,
 	}
 	if len(p.SubCgroupPaths) > 0 {
 		if add, ok := p.SubCgroupPaths[""]; ok {
@@ -661,6 +904,10 @@ func (c *Container) newInitConfig(process *Process) *initConfig {
 		AppArmorProfile:  c.config.AppArmorProfile,
 		ProcessLabel:     c.config.ProcessLabel,
 		Rlimits:          c.config.Rlimits,
+		Scheduler:        c.config.Scheduler,
+		IOPriority:       c.config.IOPriority,
+		CPUAffinity:      c.config.CPUAffinity,
+		Landlock:         c.config.Landlock,
 		CreateConsole:    process.ConsoleSocket != nil,
 		ConsoleWidth:     process.ConsoleWidth,
 		ConsoleHeight:    process.ConsoleHeight,
@@ -677,6 +924,18 @@ func (c *Container) newInitConfig(process *Process) *initConfig {
 	if len(process.Rlimits) > 0 {
 		cfg.Rlimits = process.Rlimits
 	}
+	if process.Scheduler != nil {
+		cfg.Scheduler = process.Scheduler
+	}
+	if process.IOPriority != nil {
+		cfg.IOPriority = process.IOPriority
+	}
+	if process.CPUAffinity != nil {
+		cfg.CPUAffinity = process.CPUAffinity
+	}
+	if process.Landlock != nil {
+		cfg.Landlock = process.Landlock
+	}
 	if cgroups.IsCgroup2UnifiedMode() {
 		cfg.Cgroup2Path = c.cgroupManager.Path("")
 	}
@@ -712,9 +971,13 @@ func (c *Container) Pause() error {
 		if err := c.cgroupManager.Freeze(configs.Frozen); err != nil {
 			return err
 		}
-		return c.state.transition(&pausedState{
+		if err := c.state.transition(&pausedState{
 			c: c,
-		})
+		}); err != nil {
+			return err
+		}
+		c.recordEvent(EventPaused, 0, nil)
+		return nil
 	}
 	return ErrNotRunning
 }
@@ -736,9 +999,13 @@ func (c *Container) Resume() error {
 	if err := c.cgroupManager.Freeze(configs.Thawed); err != nil {
 		return err
 	}
-	return c.state.transition(&runningState{
+	if err := c.state.transition(&runningState{
 		c: c,
-	})
+	}); err != nil {
+		return err
+	}
+	c.recordEvent(EventResumed, 0, nil)
+	return nil
 }
 
 // NotifyOOM returns a read-only channel signaling when the container receives
@@ -755,16 +1022,54 @@ func (c *Container) NotifyOOM() (<-chan struct{}, error) {
 	return notifyOnOOM(path)
 }
 
+// NotifyMemoryEvents returns a read-only channel on which the memory event
+// counters of the container (see cgroups.MemoryEvents) are sent, first as
+// they are, and then every time they change, which requires cgroup v2. If
+// the counters are not received before they change again, only the latest
+// ones are sent. The channel is closed once the container has no more
+// processes.
+func (c *Container) NotifyMemoryEvents() (<-chan cgroups.MemoryEvents, error) {
+	if !cgroups.IsCgroup2UnifiedMode() {
+		return nil, errors.New("memory event notifications require cgroup v2")
+	}
+	if c.config.RootlessCgroups {
+		logrus.Warn("getting memory event notifications may fail if you don't have the full access to cgroups")
+	}
+	return notifyMemoryEventsV2(c.cgroupManager.Path(""))
+}
+
 // NotifyMemoryPressure returns a read-only channel signaling when the
-// container reaches a given pressure level.
+// container reaches a given pressure level. With cgroup v2, the levels are
+// mapped to fixed memory PSI triggers (see NotifyPressure for custom ones).
 func (c *Container) NotifyMemoryPressure(level PressureLevel) (<-chan struct{}, error) {
 	// XXX(cyphar): This requires cgroups.
 	if c.config.RootlessCgroups {
 		logrus.Warn("getting memory pressure notifications may fail if you don't have the full access to cgroups")
 	}
+	if cgroups.IsCgroup2UnifiedMode() {
+		t, err := memoryPressureTrigger(level)
+		if err != nil {
+			return nil, err
+		}
+		return registerPSITrigger(context.Background(), c.cgroupManager.Path(""), t)
+	}
 	return notifyMemoryPressure(c.cgroupManager.Path("memory"), level)
 }
 
+// NotifyPressure returns a read-only channel signaling every time the PSI
+// trigger t fires for the container, which requires cgroup v2. The trigger
+// is unregistered and the channel is closed once ctx is done or the
+// container cgroup is removed.
+func (c *Container) NotifyPressure(ctx context.Context, t PSITrigger) (<-chan struct{}, error) {
+	if !cgroups.IsCgroup2UnifiedMode() {
+		return nil, errors.New("pressure notifications require cgroup v2")
+	}
+	if c.config.RootlessCgroups {
+		logrus.Warn("getting pressure notifications may fail if you don't have the full access to cgroups")
+	}
+	return registerPSITrigger(ctx, c.cgroupManager.Path(""), t)
+}
+
 var criuFeatures *criurpc.CriuFeatures
 
 func (c *Container) checkCriuFeatures(criuOpts *CriuOpts, rpcOpts *criurpc.CriuOpts, criuFeat *criurpc.CriuFeatures) error {
@@ -861,6 +1166,9 @@ func (c *Container) addCriuDumpMount(req *criurpc.CriuReq, m *configs.Mount) {
 
 func (c *Container) addMaskPaths(req *criurpc.CriuReq) error {
 	for _, path := range c.config.MaskPaths {
+		if procHidden(c.config, path) {
+			continue
+		}
 		fi, err := os.Stat(fmt.Sprintf("/proc/%d/root/%s", c.initProcess.pid(), path))
 		if err != nil {
 			if os.IsNotExist(err) {
@@ -972,8 +1280,6 @@ func (c *Container) handleRestoringNamespaces(rpcOpts *criurpc.CriuOpts, extraFi
 				// CRIU has no code to handle NEWCGROUP
 				return fmt.Errorf("Do not know how to handle namespace %v", ns.Type)
 			}
-			// CRIU has code to handle NEWTIME, but it does not seem to be defined in runc
-
 			// CRIU will issue a warning for NEWUSER:
 			// criu/namespaces.c: 'join-ns with user-namespace is not fully tested and dangerous'
 			rpcOpts.JoinNs = append(rpcOpts.JoinNs, &criurpc.JoinNamespace{
@@ -1031,6 +1337,12 @@ func (c *Container) Checkpoint(criuOpts *CriuOpts) error {
 	if err := c.checkCriuVersion(30000); err != nil {
 		return err
 	}
+	// Time namespaces are supported since CRIU 3.14.
+	if c.config.Namespaces.Contains(configs.NEWTIME) {
+		if err := c.checkCriuVersion(31400); err != nil {
+			return err
+		}
+	}
 
 	if criuOpts.ImagesDirectory == "" {
 		return errors.New("invalid directory to save checkpoint")
@@ -1387,6 +1699,12 @@ func (c *Container) Restore(process *Process, criuOpts *CriuOpts) error {
 	if err := c.checkCriuVersion(30000); err != nil {
 		return err
 	}
+	// Time namespaces are supported since CRIU 3.14.
+	if c.config.Namespaces.Contains(configs.NEWTIME) {
+		if err := c.checkCriuVersion(31400); err != nil {
+			return err
+		}
+	}
 	if criuOpts.ImagesDirectory == "" {
 		return errors.New("invalid directory to restore checkpoint")
 	}
@@ -1498,9 +1816,13 @@ func (c *Container) Restore(process *Process, criuOpts *CriuOpts) error {
 		}
 	}
 
-	if len(c.config.MaskPaths) > 0 {
-		m := &configs.Mount{Destination: "/dev/null", Source: "/dev/null"}
-		c.addCriuRestoreMount(req, m)
+	for _, path := range c.config.MaskPaths {
+		// The masked files are bind mounts of /dev/null (see addMaskPaths).
+		if !procHidden(c.config, path) {
+			m := &configs.Mount{Destination: "/dev/null", Source: "/dev/null"}
+			c.addCriuRestoreMount(req, m)
+			break
+		}
 	}
 
 	for _, node := range c.config.Devices {
@@ -1864,6 +2186,7 @@ func (c *Container) criuNotifications(resp *criurpc.CriuResp, process *Process,
 		if _, err := c.updateState(r); err != nil {
 			return err
 		}
+		c.recordEvent(EventStarted, int(pid), nil)
 		if err := os.Remove(filepath.Join(c.root, "checkpoint")); err != nil {
 			if !os.IsNotExist(err) {
 				logrus.Error(err)
@@ -1915,8 +2238,66 @@ func (c *Container) updateState(process parentProcess) (*State, error) {
 	return state, nil
 }
 
-func (c *Container) saveState(s *State) (retErr error) {
-	tmpFile, err := os.CreateTemp(c.root, "state-")
+func (c *Container) saveState(s *State) error {
+	return c.writeJSONFile(stateFilename, s)
+}
+
+// RecordExit saves the wait status of the container's init process, so that
+// it is reported by State (including by containers obtained through Load)
+// once the container has stopped. It only needs to be called by the callers
+// which reap the init process themselves, rather than with Process.Wait.
+func (c *Container) RecordExit(ws unix.WaitStatus) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.saveExitStatus(ws)
+}
+
+func (c *Container) saveExitStatus(ws unix.WaitStatus) error {
+	es := c.initExitStatus(ws)
+	if err := c.writeJSONFile(exitFilename, es); err != nil {
+		return err
+	}
+	var pid int
+	if c.initProcess != nil {
+		pid = c.initProcess.pid()
+	}
+	c.recordEvent(EventStopped, pid, es)
+	return nil
+}
+
+// initExitStatus returns the exit status of init, which exited with ws.
+func (c *Container) initExitStatus(ws unix.WaitStatus) *ExitStatus {
+	es := newExitStatus(ws)
+	// The cgroup might be gone already (e.g. removed by systemd), in which
+	// case we can't tell whether there was an OOM kill.
+	if oom, err := c.cgroupManager.OOMKillCount(); err == nil && oom > 0 {
+		es.OOMKilled = true
+	}
+	return es
+}
+
+// RecordExecExit records the exit of a process started in the container
+// (other than init) as an exec-exited lifecycle event. Like RecordExit, it
+// only needs to be called by the callers which reap the process themselves.
+func (c *Container) RecordExecExit(pid int, ws unix.WaitStatus) {
+	c.recordEvent(EventExecExited, pid, newExitStatus(ws))
+}
+
+func newExitStatus(ws unix.WaitStatus) *ExitStatus {
+	es := &ExitStatus{
+		ExitCode:   utils.ExitStatus(ws),
+		FinishedAt: time.Now().UTC(),
+	}
+	if ws.Signaled() {
+		es.Signal = int(ws.Signal())
+	}
+	return es
+}
+
+// writeJSONFile atomically replaces the file name in the container state
+// directory with the JSON encoding of v.
+func (c *Container) writeJSONFile(name string, v interface{}) (retErr error) {
+	tmpFile, err := os.CreateTemp(c.root, strings.TrimSuffix(name, ".json")+"-")
 	if err != nil {
 		return err
 	}
@@ -1928,7 +2309,7 @@ func (c *Container) saveState(s *State) (retErr error) {
 		}
 	}()
 
-	err = utils.WriteJSON(tmpFile, s)
+	err = utils.WriteJSON(tmpFile, v)
 	if err != nil {
 		return err
 	}
@@ -1937,8 +2318,7 @@ func (c *Container) saveState(s *State) (retErr error) {
 		return err
 	}
 
-	stateFilePath := filepath.Join(c.root, stateFilename)
-	return os.Rename(tmpFile.Name(), stateFilePath)
+	return os.Rename(tmpFile.Name(), filepath.Join(c.root, name))
 }
 
 func (c *Container) currentStatus() (Status, error) {
@@ -1967,6 +2347,7 @@ func (c *Container) refreshState() error {
 	case Running:
 		return c.state.transition(&runningState{c: c})
 	}
+	c.recordStopped()
 	return c.state.transition(&stoppedState{c: c})
 }
 
@@ -1974,6 +2355,11 @@ func (c *Container) runType() Status {
 	if c.initProcess == nil {
 		return Stopped
 	}
+	// If we have a pidfd for init, it tells whether init has exited
+	// without relying on its pid.
+	if initPidHandle(c.initProcess).exited() {
+		return Stopped
+	}
 	pid := c.initProcess.pid()
 	stat, err := system.Stat(pid)
 	if err != nil {
@@ -2014,6 +2400,10 @@ func (c *Container) currentState() (*State, error) {
 	if c.intelRdtManager != nil {
 		intelRdtPath = c.intelRdtManager.GetPath()
 	}
+	exitStatus, err := loadExitStatus(c.root)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load exit status: %w", err)
+	}
 	state := &State{
 		BaseState: BaseState{
 			ID:                   c.ID(),
@@ -2021,6 +2411,7 @@ func (c *Container) currentState() (*State, error) {
 			InitProcessPid:       pid,
 			InitProcessStartTime: startTime,
 			Created:              c.created,
+			ExitStatus:           exitStatus,
 		},
 		Rootless:            c.config.RootlessEUID && c.config.RootlessCgroups,
 		CgroupPaths:         c.cgroupManager.GetPaths(),
@@ -2110,6 +2501,21 @@ func encodeIDMapping(idMap []configs.IDMap) ([]byte, error) {
 	return data.Bytes(), nil
 }
 
+// encodeTimeOffsets encodes the clock offsets in the format of
+// /proc/<pid>/timens_offsets, see time_namespaces(7).
+func encodeTimeOffsets(offsets map[string]specs.LinuxTimeOffset) []byte {
+	clocks := make([]string, 0, len(offsets))
+	for clock := range offsets {
+		clocks = append(clocks, clock)
+	}
+	sort.Strings(clocks)
+	data := bytes.NewBuffer(nil)
+	for _, clock := range clocks {
+		fmt.Fprintf(data, "%s %d %d\n", clock, offsets[clock].Secs, offsets[clock].Nanosecs)
+	}
+	return data.Bytes()
+}
+
 // netlinkError is an error wrapper type for use by custom netlink message
 // types. Panics with errors are wrapped in netlinkError so that the recover
 // in bootstrapData can distinguish intentional panics.
@@ -2121,7 +2527,7 @@ type netlinkError struct{ error }
 // such as one that uses nsenter package to bootstrap the container's
 // init process correctly, i.e. with correct namespaces, uid/gid
 // mapping etc.
-func (c *Container) bootstrapData(cloneFlags uintptr, nsMaps map[configs.NamespaceType]string, it initType) (_ io.Reader, Err error) {
+func (c *Container) bootstrapData(cloneFlags uintptr, nsMaps map[configs.NamespaceType]string, it initType, idmapUserns []string) (_ io.Reader, Err error) {
 	// create the netlink message
 	r := nl.NewNetlinkRequest(int(InitMsg), 0)
 
@@ -2209,6 +2615,14 @@ func (c *Container) bootstrapData(cloneFlags uintptr, nsMaps map[configs.Namespa
 		}
 	}
 
+	// write the clock offsets, only when creating a new time namespace
+	if cloneFlags&unix.CLONE_NEWTIME != 0 && len(c.config.TimeOffsets) > 0 {
+		r.AddData(&Bytemsg{
+			Type:  TimeOffsetsAttr,
+			Value: encodeTimeOffsets(c.config.TimeOffsets),
+		})
+	}
+
 	if c.config.OomScoreAdj != nil {
 		// write oom_score_adj
 		r.AddData(&Bytemsg{
@@ -2227,7 +2641,7 @@ func (c *Container) bootstrapData(cloneFlags uintptr, nsMaps map[configs.Namespa
 	if it == initStandard && c.shouldSendMountSources() {
 		var mounts []byte
 		for _, m := range c.config.Mounts {
-			if m.IsBind() {
+			if m.IsBind() && !m.IsIDMapped() {
 				if strings.IndexByte(m.Source, 0) >= 0 {
 					return nil, fmt.Errorf("mount source string contains null byte: %q", m.Source)
 				}
@@ -2242,6 +2656,41 @@ func (c *Container) bootstrapData(cloneFlags uintptr, nsMaps map[configs.Namespa
 		})
 	}
 
+	// Id-mapped mounts to create, see nsexec.c send_idmapsources().
+	if it == initStandard && c.hasIDMappedMounts() {
+		var sources []byte
+		for i, m := range c.config.Mounts {
+			if !m.IsIDMapped() {
+				sources = append(sources, 0, 0, 0, 0)
+				continue
+			}
+			if strings.IndexByte(m.Source, 0) >= 0 {
+				return nil, fmt.Errorf("mount source string contains null byte: %q", m.Source)
+			}
+			treeFlags := unix.OPEN_TREE_CLONE | unix.OPEN_TREE_CLOEXEC
+			if m.Flags&unix.MS_REC != 0 {
+				treeFlags |= unix.AT_RECURSIVE
+			}
+			attrFlags := unix.AT_EMPTY_PATH
+			if m.IDMapping.Recursive {
+				attrFlags |= unix.AT_RECURSIVE
+			}
+			var usernsPath string
+			if idmapUserns != nil {
+				usernsPath = idmapUserns[i]
+			}
+			for _, f := range []string{m.Source, usernsPath, strconv.Itoa(treeFlags), strconv.Itoa(attrFlags)} {
+				sources = append(sources, f...)
+				sources = append(sources, 0)
+			}
+		}
+
+		r.AddData(&Bytemsg{
+			Type:  IdmapSourcesAttr,
+			Value: sources,
+		})
+	}
+
 	return bytes.NewReader(r.Serialize()), nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/system"
+	"golang.org/x/sys/unix"
 )
 
 type mockCgroupManager struct {
@@ -49,6 +50,10 @@ func (m *mockCgroupManager) OOMKillCount() (uint64, error) {
 	return 0, nil
 }
 
+func (m *mockCgroupManager) Kill() error {
+	return cgroups.ErrKillUnsupported
+}
+
 func (m *mockCgroupManager) GetPaths() map[string]string {
 	return m.paths
 }
@@ -286,3 +291,48 @@ func TestGetContainerStateAfterUpdate(t *testing.T) {
 		t.Fatalf("expected Memory to be 2048 but received %q", state.Config.Cgroups.Memory)
 	}
 }
+
+func TestRecordExit(t *testing.T) {
+	container := &Container{
+		root:          t.TempDir(),
+		id:            "myid",
+		config:        &configs.Config{},
+		cgroupManager: &mockCgroupManager{},
+	}
+	container.state = &stoppedState{c: container}
+
+	state, err := container.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.ExitStatus != nil {
+		t.Fatalf("expected no exit status, got %+v", state.ExitStatus)
+	}
+
+	for _, tc := range []struct {
+		ws       unix.WaitStatus
+		exitCode int
+		signal   int
+	}{
+		{ws: 3 << 8, exitCode: 3},
+		{ws: unix.WaitStatus(unix.SIGKILL), exitCode: 137, signal: int(unix.SIGKILL)},
+	} {
+		if err := container.RecordExit(tc.ws); err != nil {
+			t.Fatal(err)
+		}
+		state, err := container.State()
+		if err != nil {
+			t.Fatal(err)
+		}
+		es := state.ExitStatus
+		if es == nil {
+			t.Fatal("expected exit status to be recorded")
+		}
+		if es.ExitCode != tc.exitCode || es.Signal != tc.signal {
+			t.Errorf("expected exit code %d and signal %d, got %+v", tc.exitCode, tc.signal, es)
+		}
+		if es.FinishedAt.IsZero() {
+			t.Error("expected finish time to be set")
+		}
+	}
+}
@@ -25,6 +25,8 @@ import (
 const (
 	stateFilename    = "state.json"
 	execFifoFilename = "exec.fifo"
+	exitFilename     = "exit.json"
+	eventsFilename   = "events.log"
 )
 
 var idRegex = regexp.MustCompile(`^[\w+-\.]+$`)
@@ -260,6 +262,28 @@ func loadState(root string) (*State, error) {
 	return state, nil
 }
 
+// loadExitStatus reads the exit status of the init process recorded in the
+// container state directory root. It returns nil if no exit was recorded.
+func loadExitStatus(root string) (*ExitStatus, error) {
+	exitFilePath, err := securejoin.SecureJoin(root, exitFilename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(exitFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var es *ExitStatus
+	if err := json.NewDecoder(f).Decode(&es); err != nil {
+		return nil, err
+	}
+	return es, nil
+}
+
 func validateID(id string) error {
 	if !idRegex.MatchString(id) || string(os.PathSeparator)+id != utils.CleanPath(string(os.PathSeparator)+id) {
 		return ErrInvalidID
@@ -268,17 +292,32 @@ func validateID(id string) error {
 	return nil
 }
 
-func parseMountFds() ([]int, error) {
-	fdsJSON := os.Getenv("_LIBCONTAINER_MOUNT_FDS")
+func parseMountFds() (mountFds, error) {
+	var (
+		fds mountFds
+		err error
+	)
+	fds.sourceFds, err = parseFdsEnv("_LIBCONTAINER_MOUNT_FDS")
+	if err != nil {
+		return fds, err
+	}
+	fds.idmapFds, err = parseFdsEnv("_LIBCONTAINER_IDMAP_FDS")
+	return fds, err
+}
+
+// parseFdsEnv parses the environment variable name, which is a json array of
+// fds, and returns nil if it is not set.
+func parseFdsEnv(name string) ([]int, error) {
+	fdsJSON := os.Getenv(name)
 	if fdsJSON == "" {
 		// Always return the nil slice if no fd is present.
 		return nil, nil
 	}
 
-	var mountFds []int
-	if err := json.Unmarshal([]byte(fdsJSON), &mountFds); err != nil {
-		return nil, fmt.Errorf("Error unmarshalling _LIBCONTAINER_MOUNT_FDS: %w", err)
+	var fds []int
+	if err := json.Unmarshal([]byte(fdsJSON), &fds); err != nil {
+		return nil, fmt.Errorf("Error unmarshalling %s: %w", name, err)
 	}
 
-	return mountFds, nil
+	return fds, nil
 }
@@ -20,6 +20,7 @@ import (
 	"github.com/opencontainers/runc/libcontainer/capabilities"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/landlock"
 	"github.com/opencontainers/runc/libcontainer/system"
 	"github.com/opencontainers/runc/libcontainer/user"
 	"github.com/opencontainers/runc/libcontainer/utils"
@@ -62,6 +63,10 @@ type initConfig struct {
 	PassedFilesCount int                   `json:"passed_files_count"`
 	ContainerID      string                `json:"containerid"`
 	Rlimits          []configs.Rlimit      `json:"rlimits"`
+	Scheduler        *configs.Scheduler    `json:"scheduler,omitempty"`
+	IOPriority       *configs.IOPriority   `json:"io_priority,omitempty"`
+	CPUAffinity      *configs.CPUAffinity  `json:"cpu_affinity,omitempty"`
+	Landlock         *configs.Landlock     `json:"landlock,omitempty"`
 	CreateConsole    bool                  `json:"create_console"`
 	ConsoleWidth     uint16                `json:"console_width"`
 	ConsoleHeight    uint16                `json:"console_height"`
@@ -71,11 +76,22 @@ type initConfig struct {
 	Cgroup2Path      string                `json:"cgroup2_path,omitempty"`
 }
 
+// mountFds are the fds of the mounts prepared by nsexec, which are paired with
+// the container mounts. Each slice is either nil, or as long as the mounts,
+// with -1 for the mounts it has no fd for. A mount has at most one fd.
+type mountFds struct {
+	// sourceFds are the sources of the bind mounts, opened with O_PATH.
+	sourceFds []int
+	// idmapFds are the id-mapped mounts, detached from the mount tree, to
+	// be attached with move_mount(2).
+	idmapFds []int
+}
+
 type initer interface {
 	Init() error
 }
 
-func newContainerInit(t initType, pipe *os.File, consoleSocket *os.File, fifoFd, logFd int, mountFds []int) (initer, error) {
+func newContainerInit(t initType, pipe *os.File, consoleSocket *os.File, fifoFd, logFd int, mountFds mountFds) (initer, error) {
 	var config *initConfig
 	if err := json.NewDecoder(pipe).Decode(&config); err != nil {
 		return nil, err
@@ -86,7 +102,7 @@ func newContainerInit(t initType, pipe *os.File, consoleSocket *os.File, fifoFd,
 	switch t {
 	case initSetns:
 		// mountFds must be nil in this case. We don't mount while doing runc exec.
-		if mountFds != nil {
+		if mountFds.sourceFds != nil || mountFds.idmapFds != nil {
 			return nil, errors.New("mountFds must be nil; can't mount from exec")
 		}
 
@@ -495,6 +511,66 @@ func setupRlimits(limits []configs.Rlimit, pid int) error {
 	return nil
 }
 
+// setupScheduler sets the scheduling policy and attributes of the calling
+// thread, which are inherited across execve(2).
+func setupScheduler(s *configs.Scheduler) error {
+	attr, err := configs.ToSchedAttr(s)
+	if err != nil {
+		return err
+	}
+	if err := system.SchedSetattr(0, attr, 0); err != nil {
+		if errors.Is(err, unix.EPERM) && (s.Policy == specs.SchedFIFO || s.Policy == specs.SchedRR || s.Policy == specs.SchedDeadline) {
+			return fmt.Errorf("error setting scheduler: %w (with cgroup v1 and CONFIG_RT_GROUP_SCHED, the container cgroup may need a non-zero cpu.rt_runtime_us)", err)
+		}
+		return fmt.Errorf("error setting scheduler: %w", err)
+	}
+	return nil
+}
+
+// setupIOPriority sets the I/O priority of the calling thread, which is
+// inherited across execve(2).
+func setupIOPriority(p *configs.IOPriority) error {
+	ioprio, err := configs.ToIOPrio(p)
+	if err != nil {
+		return err
+	}
+	if err := system.IOPrioSet(ioprio); err != nil {
+		return fmt.Errorf("error setting I/O priority: %w", err)
+	}
+	return nil
+}
+
+// setupMemoryPolicy sets the NUMA memory policy of the calling thread, which
+// is inherited across execve(2).
+func setupMemoryPolicy(p *configs.LinuxMemoryPolicy) error {
+	var (
+		nodes   unsafe.Pointer
+		maxnode uint
+	)
+	if p.Nodes != nil {
+		nodes = unsafe.Pointer(p.Nodes)
+		// set_mempolicy(2) reads one bit less than maxnode.
+		maxnode = uint(unsafe.Sizeof(*p.Nodes)*8) + 1
+	}
+	if err := system.SetMempolicy(p.Mode|p.Flags, nodes, maxnode); err != nil {
+		return fmt.Errorf("error setting memory policy: %w", err)
+	}
+	return nil
+}
+
+// setupLandlock restricts the calling thread with the Landlock ruleset of
+// the process, in addition to the given rules.
+func setupLandlock(config *initConfig, fdRules ...landlock.FDRule) error {
+	// The configuration of an exec'd process is not validated as a whole.
+	if !config.NoNewPrivileges {
+		return errors.New("landlock requires noNewPrivileges to be set")
+	}
+	if err := landlock.Apply(config.Landlock, fdRules...); err != nil {
+		return fmt.Errorf("unable to apply landlock ruleset: %w", err)
+	}
+	return nil
+}
+
 const _P_PID = 1
 
 //nolint:structcheck,unused
@@ -520,37 +596,28 @@ func isWaitable(pid int) (bool, error) {
 	return si.si_pid != 0, nil
 }
 
-// signalAllProcesses freezes then iterates over all the processes inside the
-// manager's cgroups sending the signal s to them.
+// signalAllProcesses sends the signal s to all the processes inside the
+// manager's cgroups. For SIGKILL, the cgroup is killed atomically if the
+// manager supports it (see cgroups.Manager.Kill), which also waits for the
+// processes to exit. Otherwise, the cgroups are frozen, and each process is
+// sent the signal.
 // If s is SIGKILL and subreaper is not enabled then it will wait for each
 // process to exit.
 // For all other signals it will check if the process is ready to report its
 // exit status and only if it is will a wait be performed.
 func signalAllProcesses(m cgroups.Manager, s os.Signal) error {
-	var procs []*os.Process
-	if err := m.Freeze(configs.Frozen); err != nil {
-		logrus.Warn(err)
-	}
-	pids, err := m.GetAllPids()
-	if err != nil {
-		if err := m.Freeze(configs.Thawed); err != nil {
-			logrus.Warn(err)
-		}
-		return err
-	}
-	for _, pid := range pids {
-		p, err := os.FindProcess(pid)
-		if err != nil {
-			logrus.Warn(err)
-			continue
+	if s == unix.SIGKILL {
+		err := m.Kill()
+		if err == nil {
+			return nil
 		}
-		procs = append(procs, p)
-		if err := p.Signal(s); err != nil {
-			logrus.Warn(err)
+		if !errors.Is(err, cgroups.ErrKillUnsupported) {
+			logrus.Warnf("unable to kill cgroup, falling back to killing each process: %v", err)
 		}
 	}
-	if err := m.Freeze(configs.Thawed); err != nil {
-		logrus.Warn(err)
+	procs, err := signalEachProcess(m, s)
+	if err != nil {
+		return err
 	}
 
 	subreaper, err := system.GetSubreaper()
@@ -591,3 +658,35 @@ func signalAllProcesses(m cgroups.Manager, s os.Signal) error {
 	}
 	return nil
 }
+
+// signalEachProcess freezes the manager's cgroups, sends the signal s to
+// each of their processes, then thaws the cgroups. It returns the processes
+// which were signaled.
+func signalEachProcess(m cgroups.Manager, s os.Signal) ([]*os.Process, error) {
+	var procs []*os.Process
+	if err := m.Freeze(configs.Frozen); err != nil {
+		logrus.Warn(err)
+	}
+	pids, err := m.GetAllPids()
+	if err != nil {
+		if err := m.Freeze(configs.Thawed); err != nil {
+			logrus.Warn(err)
+		}
+		return nil, err
+	}
+	for _, pid := range pids {
+		p, err := os.FindProcess(pid)
+		if err != nil {
+			logrus.Warn(err)
+			continue
+		}
+		procs = append(procs, p)
+		if err := p.Signal(s); err != nil {
+			logrus.Warn(err)
+		}
+	}
+	if err := m.Freeze(configs.Thawed); err != nil {
+		logrus.Warn(err)
+	}
+	return procs, nil
+}
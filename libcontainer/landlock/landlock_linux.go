@@ -0,0 +1,247 @@
+// Package landlock restricts the filesystem accesses of processes using
+// Landlock (see landlock(7)).
+package landlock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// The access rights which are not in the vendored golang.org/x/sys/unix yet.
+const (
+	accessFSTruncate = 0x4000 // LANDLOCK_ACCESS_FS_TRUNCATE
+	accessFSIoctlDev = 0x8000 // LANDLOCK_ACCESS_FS_IOCTL_DEV
+)
+
+var accessFS = map[string]uint64{
+	"execute":     unix.LANDLOCK_ACCESS_FS_EXECUTE,
+	"write_file":  unix.LANDLOCK_ACCESS_FS_WRITE_FILE,
+	"read_file":   unix.LANDLOCK_ACCESS_FS_READ_FILE,
+	"read_dir":    unix.LANDLOCK_ACCESS_FS_READ_DIR,
+	"remove_dir":  unix.LANDLOCK_ACCESS_FS_REMOVE_DIR,
+	"remove_file": unix.LANDLOCK_ACCESS_FS_REMOVE_FILE,
+	"make_char":   unix.LANDLOCK_ACCESS_FS_MAKE_CHAR,
+	"make_dir":    unix.LANDLOCK_ACCESS_FS_MAKE_DIR,
+	"make_reg":    unix.LANDLOCK_ACCESS_FS_MAKE_REG,
+	"make_sock":   unix.LANDLOCK_ACCESS_FS_MAKE_SOCK,
+	"make_fifo":   unix.LANDLOCK_ACCESS_FS_MAKE_FIFO,
+	"make_block":  unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK,
+	"make_sym":    unix.LANDLOCK_ACCESS_FS_MAKE_SYM,
+	"refer":       unix.LANDLOCK_ACCESS_FS_REFER,
+	"truncate":    accessFSTruncate,
+	"ioctl_dev":   accessFSIoctlDev,
+}
+
+// fileAccessFS are the access rights which can be allowed on a file (rather
+// than on a directory and the files beneath it).
+const fileAccessFS = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+	unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+	unix.LANDLOCK_ACCESS_FS_READ_FILE |
+	accessFSTruncate |
+	accessFSIoctlDev
+
+// abiAccessFS returns the access rights supported by the given Landlock ABI
+// version.
+func abiAccessFS(abi int) uint64 {
+	switch {
+	case abi <= 0:
+		return 0
+	case abi == 1:
+		return unix.LANDLOCK_ACCESS_FS_MAKE_SYM<<1 - 1
+	case abi == 2:
+		return unix.LANDLOCK_ACCESS_FS_REFER<<1 - 1
+	case abi < 5:
+		return accessFSTruncate<<1 - 1
+	default:
+		return accessFSIoctlDev<<1 - 1
+	}
+}
+
+// ParseAccessFS converts the names of filesystem access rights (such as
+// "read_file") to the corresponding LANDLOCK_ACCESS_FS_* bits.
+func ParseAccessFS(names []string) (uint64, error) {
+	var access uint64
+	for _, name := range names {
+		a, ok := accessFS[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown landlock filesystem access right %q", name)
+		}
+		access |= a
+	}
+	return access, nil
+}
+
+// formatAccessFS converts LANDLOCK_ACCESS_FS_* bits to their names.
+func formatAccessFS(access uint64) string {
+	var names []string
+	for name, a := range accessFS {
+		if access&a != 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// Validate checks that the Landlock ruleset is well formed. It does not
+// check whether the kernel supports it.
+func Validate(ll *configs.Landlock) error {
+	handled, err := ParseAccessFS(ll.HandledAccessFS)
+	if err != nil {
+		return err
+	}
+	for _, r := range ll.Rules {
+		allowed, err := ParseAccessFS(r.AllowedAccess)
+		if err != nil {
+			return err
+		}
+		if allowed == 0 {
+			return errors.New("landlock rule without allowed access rights")
+		}
+		if len(ll.HandledAccessFS) > 0 && allowed&^handled != 0 {
+			return fmt.Errorf("landlock rule allows access rights which are not handled by the ruleset: %s", formatAccessFS(allowed&^handled))
+		}
+		if len(r.Paths) == 0 {
+			return errors.New("landlock rule without paths")
+		}
+		for _, p := range r.Paths {
+			if !filepath.IsAbs(p) {
+				return fmt.Errorf("landlock rule path %q is not absolute", p)
+			}
+		}
+	}
+	return nil
+}
+
+// ABIVersion returns the version of the Landlock ABI supported by the
+// kernel, or 0 if Landlock is not supported (or disabled).
+func ABIVersion() int {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+// FDRule allows filesystem access rights on an already opened file or
+// directory, in addition to the rules of a ruleset.
+type FDRule struct {
+	Fd     int
+	Access []string
+}
+
+// Apply restricts the calling thread (and its future children) with the
+// Landlock ruleset and the additional fdRules. The access rights which are
+// not supported by the kernel are dropped from the ruleset, and if Landlock
+// is not supported at all, a warning is logged and nothing is done.
+//
+// As required by the kernel, the calling thread must either have
+// no_new_privs set, or have CAP_SYS_ADMIN in its user namespace.
+func Apply(ll *configs.Landlock, fdRules ...FDRule) error {
+	abi := ABIVersion()
+	if abi == 0 {
+		logrus.Warn("landlock is not supported by the kernel, the landlock ruleset is ignored")
+		return nil
+	}
+	supported := abiAccessFS(abi)
+
+	handled := supported
+	if len(ll.HandledAccessFS) > 0 {
+		h, err := ParseAccessFS(ll.HandledAccessFS)
+		if err != nil {
+			return err
+		}
+		if h&^supported != 0 {
+			logrus.Debugf("landlock: ignoring access rights not supported by ABI version %d: %s", abi, formatAccessFS(h&^supported))
+		}
+		handled = h & supported
+	}
+	if handled == 0 {
+		return nil
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return &os.SyscallError{Syscall: "landlock_create_ruleset", Err: errno}
+	}
+	rulesetFd := int(fd)
+	defer unix.Close(rulesetFd)
+
+	for _, r := range ll.Rules {
+		allowed, err := ParseAccessFS(r.AllowedAccess)
+		if err != nil {
+			return err
+		}
+		for _, p := range r.Paths {
+			if err := addPathRule(rulesetFd, p, allowed&handled); err != nil {
+				return err
+			}
+		}
+	}
+	for _, r := range fdRules {
+		allowed, err := ParseAccessFS(r.Access)
+		if err != nil {
+			return err
+		}
+		if err := addRule(rulesetFd, r.Fd, allowed&handled); err != nil {
+			return fmt.Errorf("unable to add landlock rule for fd %d: %w", r.Fd, err)
+		}
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(rulesetFd), 0, 0); errno != 0 {
+		return &os.SyscallError{Syscall: "landlock_restrict_self", Err: errno}
+	}
+	return nil
+}
+
+func addPathRule(rulesetFd int, path string, allowed uint64) error {
+	if allowed == 0 {
+		return nil
+	}
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "open landlock rule path", Path: path, Err: err}
+	}
+	defer unix.Close(fd)
+	if err := addRule(rulesetFd, fd, allowed); err != nil {
+		return fmt.Errorf("unable to add landlock rule for %s: %w", path, err)
+	}
+	return nil
+}
+
+func addRule(rulesetFd, fd int, allowed uint64) error {
+	if allowed == 0 {
+		return nil
+	}
+	// Only some of the access rights can be allowed on a file.
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return &os.SyscallError{Syscall: "fstat", Err: err}
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		allowed &= fileAccessFS
+		if allowed == 0 {
+			return nil
+		}
+	}
+	attr := unix.LandlockPathBeneathAttr{
+		Allowed_access: allowed,
+		Parent_fd:      int32(fd),
+	}
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFd), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&attr)), 0, 0, 0)
+	if errno != 0 {
+		return &os.SyscallError{Syscall: "landlock_add_rule", Err: errno}
+	}
+	return nil
+}
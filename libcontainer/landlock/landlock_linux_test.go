@@ -0,0 +1,80 @@
+package landlock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestParseAccessFS(t *testing.T) {
+	access, err := ParseAccessFS([]string{"read_file", "read_dir", "ioctl_dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := uint64(unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR | accessFSIoctlDev); access != exp {
+		t.Errorf("expected %#x, got %#x", exp, access)
+	}
+	if _, err := ParseAccessFS([]string{"read_file", "read"}); err == nil {
+		t.Error("expected an error for an unknown access right")
+	}
+}
+
+func TestABIAccessFS(t *testing.T) {
+	for abi, exp := range map[int]uint64{0: 0, 1: 0x1fff, 2: 0x3fff, 3: 0x7fff, 4: 0x7fff, 5: 0xffff, 6: 0xffff} {
+		if got := abiAccessFS(abi); got != exp {
+			t.Errorf("ABI %d: expected %#x, got %#x", abi, exp, got)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	if ABIVersion() == 0 {
+		t.Skip("landlock is not supported")
+	}
+	dir := t.TempDir()
+	allowed := filepath.Join(dir, "allowed")
+	if err := os.Mkdir(allowed, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ll := &configs.Landlock{
+		HandledAccessFS: []string{"make_reg", "write_file"},
+		Rules: []configs.LandlockRule{
+			{AllowedAccess: []string{"make_reg", "write_file"}, Paths: []string{allowed}},
+		},
+	}
+
+	// Landlock restricts the calling thread only, which is never unlocked,
+	// so that it is terminated rather than reused by other goroutines.
+	errCh := make(chan error)
+	go func() {
+		runtime.LockOSThread()
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			errCh <- err
+			return
+		}
+		if err := Apply(ll); err != nil {
+			errCh <- err
+			return
+		}
+		if err := os.WriteFile(filepath.Join(allowed, "file"), nil, 0o644); err != nil {
+			errCh <- err
+			return
+		}
+		err := os.WriteFile(filepath.Join(dir, "file"), nil, 0o644)
+		if !errors.Is(err, os.ErrPermission) {
+			errCh <- fmt.Errorf("expected EACCES creating a file outside of the allowed directory, got %v", err)
+			return
+		}
+		errCh <- nil
+	}()
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+}
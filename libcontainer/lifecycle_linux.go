@@ -0,0 +1,254 @@
+package libcontainer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/system"
+)
+
+// maxEventSize is the maximum length of a line of the event log, which is
+// much more than what any event takes.
+const maxEventSize = 4096
+
+// recordEvent appends a lifecycle event to the event log of the container.
+// Failing to record an event does not fail the operation it is about, so
+// errors are only logged.
+//
+// The stopped event is only recorded once, as the exit of init can be seen
+// by several runc processes (see recordStopped).
+func (c *Container) recordEvent(typ LifecycleEventType, pid int, es *ExitStatus) {
+	e := &LifecycleEvent{Type: typ, Pid: pid, ExitStatus: es}
+	if err := appendEvent(filepath.Join(c.root, eventsFilename), e, typ == EventStopped); err != nil {
+		// The state directory is gone if the container has been destroyed.
+		if errors.Is(err, os.ErrNotExist) {
+			logrus.Debugf("unable to record %s event: %v", typ, err)
+			return
+		}
+		logrus.Warnf("unable to record %s event: %v", typ, err)
+	}
+}
+
+// appendEvent sets the sequence number and timestamp of e, and appends it to
+// the event log at path. If once is set, e is not appended if the log has an
+// event of the same type already.
+func appendEvent(path string, e *LifecycleEvent, once bool) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// The events of a container are recorded by different runc processes,
+	// so the sequence numbers must be allocated under a lock.
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		return &os.PathError{Op: "flock", Path: path, Err: err}
+	}
+	if once {
+		if found, err := hasEvent(f, e.Type); err != nil || found {
+			return err
+		}
+	}
+	seq, err := lastEventSeq(f)
+	if err != nil {
+		return err
+	}
+	e.Seq = seq + 1
+	e.Timestamp = time.Now().UTC()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// hasEvent tells whether the event log f has an event of type typ.
+func hasEvent(f *os.File, typ LifecycleEventType) (bool, error) {
+	sc := bufio.NewScanner(io.NewSectionReader(f, 0, math.MaxInt64))
+	for sc.Scan() {
+		var e LifecycleEvent
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return false, fmt.Errorf("invalid event in %s: %w", f.Name(), err)
+		}
+		if e.Type == typ {
+			return true, nil
+		}
+	}
+	return false, sc.Err()
+}
+
+// lastEventSeq returns the sequence number of the last event in the event
+// log f, or 0 if it is empty.
+func lastEventSeq(f *os.File) (uint64, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if fi.Size() == 0 {
+		return 0, nil
+	}
+	off := fi.Size() - maxEventSize
+	if off < 0 {
+		off = 0
+	}
+	buf := make([]byte, fi.Size()-off)
+	if _, err := f.ReadAt(buf, off); err != nil {
+		return 0, err
+	}
+	buf = bytes.TrimSuffix(buf, []byte{'\n'})
+	if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		buf = buf[i+1:]
+	}
+	var e LifecycleEvent
+	if err := json.Unmarshal(buf, &e); err != nil {
+		return 0, fmt.Errorf("invalid last event in %s: %w", f.Name(), err)
+	}
+	return e.Seq, nil
+}
+
+// recordStopped records the stopped event, unless it has been already, once
+// init has exited. This is for the containers whose init is not waited for by
+// a runc process recording its exit (see RecordExit), such as detached ones,
+// so it is called whenever the container is seen to be stopped. The exit
+// status of init is known as long as it has not been reaped.
+func (c *Container) recordStopped() {
+	// Until its start time is known, init is still being started.
+	if c.initProcess == nil || c.initProcessStartTime == 0 {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(c.root, exitFilename)); err == nil {
+		return
+	}
+	pid := c.initProcess.pid()
+	var es *ExitStatus
+	if stat, err := system.Stat(pid); err == nil && stat.StartTime == c.initProcessStartTime {
+		if stat.State != system.Zombie {
+			return
+		}
+		es = c.initExitStatus(unix.WaitStatus(stat.ExitCode))
+	}
+	c.recordEvent(EventStopped, pid, es)
+}
+
+// pollStopped tells whether init has exited, recording the stopped event
+// if need be (see recordStopped).
+func (c *Container) pollStopped() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.initProcess == nil {
+		return true
+	}
+	if c.runType() != Stopped {
+		return false
+	}
+	c.recordStopped()
+	return true
+}
+
+// NotifyLifecycle returns a channel on which the lifecycle events of the
+// container with a sequence number greater than after are sent: first the
+// ones already recorded, then the new ones as they are recorded. This allows
+// a consumer to resume from the last event it has seen.
+//
+// The channel is closed after the deleted event has been sent, if the
+// container is destroyed without recording it, or once ctx is done.
+func (c *Container) NotifyLifecycle(ctx context.Context, after uint64) (<-chan LifecycleEvent, error) {
+	if _, err := os.Stat(c.root); err != nil {
+		return nil, err
+	}
+	ch := make(chan LifecycleEvent)
+	// Unless a runc process waits for init, its exit is only recorded once
+	// it is seen (see recordStopped), so look for it while following.
+	stopped := false
+	poll := func() {
+		if !stopped {
+			stopped = c.pollStopped()
+		}
+	}
+	go func() {
+		defer close(ch)
+		if err := followEvents(ctx, c.root, after, ch, poll); err != nil {
+			logrus.Warnf("lifecycle events: %v", err)
+		}
+	}()
+	return ch, nil
+}
+
+// followEvents sends the events from the event log in the state directory
+// root to ch, until the deleted event, until root is gone, or until ctx is
+// done. poll (if not nil) is called each time the end of the log is reached.
+func followEvents(ctx context.Context, root string, after uint64, ch chan<- LifecycleEvent, poll func()) error {
+	var (
+		f       *os.File
+		r       *bufio.Reader
+		partial []byte
+	)
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+	for {
+		// Check the state directory before reading, so that the events
+		// recorded before it was removed are sent.
+		_, err := os.Stat(root)
+		gone := errors.Is(err, os.ErrNotExist)
+		if f == nil {
+			// The log is created along with the first event.
+			f, err = os.Open(filepath.Join(root, eventsFilename))
+			if err == nil {
+				r = bufio.NewReaderSize(f, maxEventSize)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		for f != nil {
+			line, err := r.ReadBytes('\n')
+			partial = append(partial, line...)
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return err
+			}
+			var e LifecycleEvent
+			if err := json.Unmarshal(partial, &e); err != nil {
+				return fmt.Errorf("invalid event: %w", err)
+			}
+			partial = partial[:0]
+			if e.Seq <= after {
+				continue
+			}
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				return nil
+			}
+			if e.Type == EventDeleted {
+				return nil
+			}
+		}
+		if gone {
+			return nil
+		}
+		if poll != nil {
+			poll()
+		}
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
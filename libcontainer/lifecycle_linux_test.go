@@ -0,0 +1,100 @@
+package libcontainer
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLifecycleEvents(t *testing.T) {
+	c := &Container{root: t.TempDir()}
+	c.recordEvent(EventCreated, 100, nil)
+	c.recordEvent(EventStarted, 100, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ch, err := c.NotifyLifecycle(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() (LifecycleEvent, bool) {
+		select {
+		case e, ok := <-ch:
+			return e, ok
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for an event")
+		}
+		return LifecycleEvent{}, false
+	}
+
+	// The events recorded so far are replayed, after the given one.
+	e, _ := next()
+	if e.Seq != 2 || e.Type != EventStarted || e.Pid != 100 || e.Timestamp.IsZero() {
+		t.Fatalf("unexpected event %+v", e)
+	}
+
+	// New events are sent as they are recorded.
+	c.recordEvent(EventStopped, 100, &ExitStatus{ExitCode: 3})
+	c.recordEvent(EventDeleted, 0, nil)
+	e, _ = next()
+	if e.Seq != 3 || e.Type != EventStopped || e.ExitStatus == nil || e.ExitStatus.ExitCode != 3 {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	e, _ = next()
+	if e.Seq != 4 || e.Type != EventDeleted {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if e, ok := next(); ok {
+		t.Fatalf("expected the channel to be closed after the deleted event, got %+v", e)
+	}
+}
+
+func TestLifecycleEventsRemoved(t *testing.T) {
+	c := &Container{root: t.TempDir()}
+	ch, err := c.NotifyLifecycle(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.recordEvent(EventCreated, 100, nil)
+	if e := <-ch; e.Seq != 1 || e.Type != EventCreated {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	// The channel is closed once the state directory is gone, even
+	// without a deleted event.
+	if err := os.RemoveAll(c.root); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e, ok := <-ch:
+		if ok {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the channel to be closed")
+	}
+}
+
+func TestLifecycleEventStoppedOnce(t *testing.T) {
+	c := &Container{root: t.TempDir()}
+	c.recordEvent(EventCreated, 100, nil)
+	c.recordEvent(EventStopped, 100, nil)
+	c.recordEvent(EventExecExited, 200, nil)
+	// The exit of init seen by another runc process is not recorded again.
+	c.recordEvent(EventStopped, 100, &ExitStatus{ExitCode: 3})
+	c.recordEvent(EventDeleted, 0, nil)
+
+	ch, err := c.NotifyLifecycle(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []LifecycleEventType
+	for e := range ch {
+		types = append(types, e.Type)
+	}
+	expected := []LifecycleEventType{EventCreated, EventStopped, EventExecExited, EventDeleted}
+	if !reflect.DeepEqual(types, expected) {
+		t.Fatalf("expected events %v, got %v", expected, types)
+	}
+}
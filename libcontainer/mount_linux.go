@@ -1,7 +1,9 @@
 package libcontainer
 
 import (
+	"errors"
 	"strconv"
+	"sync"
 
 	"golang.org/x/sys/unix"
 )
@@ -68,6 +70,29 @@ func mount(source, target, procfd, fstype string, flags uintptr, data string) er
 	return nil
 }
 
+// moveMount attaches the detached mount fd (see open_tree(2)) to target, using
+// move_mount(2). If procfd is not empty, it is used instead of target.
+func moveMount(fd int, target, procfd string) error {
+	dst := target
+	flags := unix.MOVE_MOUNT_F_EMPTY_PATH
+	if procfd != "" {
+		dst = procfd
+		// Unlike mount(2), move_mount(2) only follows the procfd magic
+		// link if asked to.
+		flags |= unix.MOVE_MOUNT_T_SYMLINKS
+	}
+	if err := unix.MoveMount(fd, "", unix.AT_FDCWD, dst, flags); err != nil {
+		return &mountError{
+			op:     "move_mount",
+			source: "/proc/self/fd/" + strconv.Itoa(fd),
+			target: target,
+			procfd: procfd,
+			err:    err,
+		}
+	}
+	return nil
+}
+
 // unmount is a simple unix.Unmount wrapper.
 func unmount(target string, flags int) error {
 	err := unix.Unmount(target, flags)
@@ -81,3 +106,24 @@ func unmount(target string, flags int) error {
 	}
 	return nil
 }
+
+var (
+	idmapSupportOnce sync.Once
+	idmapSupportErr  error
+)
+
+// checkIDMappedMountSupport checks (once per process) that the kernel supports
+// id-mapped mounts. Whether the filesystem of an id-mapped mount supports them
+// is only found out when nsexec creates it (see send_idmapsources).
+func checkIDMappedMountSupport() error {
+	idmapSupportOnce.Do(func() {
+		// mount_setattr(2) and MOUNT_ATTR_IDMAP came together (Linux 5.12),
+		// and open_tree(2) before. With an invalid fd, mount_setattr fails
+		// with EBADF rather than ENOSYS if it is supported.
+		err := unix.MountSetattr(-1, "", unix.AT_EMPTY_PATH, &unix.MountAttr{})
+		if errors.Is(err, unix.ENOSYS) {
+			idmapSupportErr = errors.New("id-mapped mounts are not supported by the kernel")
+		}
+	})
+	return idmapSupportErr
+}
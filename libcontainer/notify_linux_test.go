@@ -1,6 +1,7 @@
 package libcontainer
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"os"
@@ -115,3 +116,54 @@ func TestNotifyMemoryPressure(t *testing.T) {
 		testMemoryNotification(t, "memory.pressure_level", f, arg)
 	}
 }
+
+func TestPSITrigger(t *testing.T) {
+	for _, tc := range []struct {
+		trigger  PSITrigger
+		expected string
+		isErr    bool
+	}{
+		{trigger: PSITrigger{Resource: "memory", Threshold: 150 * time.Millisecond, Window: time.Second}, expected: "some 150000 1000000"},
+		{trigger: PSITrigger{Resource: "io", Full: true, Threshold: time.Second, Window: 10 * time.Second}, expected: "full 1000000 10000000"},
+		{trigger: PSITrigger{Resource: "pids", Threshold: time.Second, Window: 2 * time.Second}, isErr: true},
+		{trigger: PSITrigger{Resource: "cpu", Threshold: 100 * time.Millisecond, Window: 100 * time.Millisecond}, isErr: true},
+		{trigger: PSITrigger{Resource: "cpu", Threshold: 3 * time.Second, Window: 2 * time.Second}, isErr: true},
+		{trigger: PSITrigger{Resource: "cpu", Window: 2 * time.Second}, isErr: true},
+	} {
+		err := tc.trigger.Validate()
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("%+v: expected error, got nil", tc.trigger)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.trigger, err)
+		}
+		if s := tc.trigger.String(); s != tc.expected {
+			t.Errorf("%+v: expected %q, got %q", tc.trigger, tc.expected, s)
+		}
+	}
+}
+
+func TestPSITriggerStop(t *testing.T) {
+	// A regular file stands in for the pressure file, which never fires.
+	cgDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cgDir, "memory.pressure"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := registerPSITrigger(ctx, cgDir, PSITrigger{Resource: "memory", Threshold: time.Second, Window: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("unexpected PSI trigger event")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the PSI trigger channel is not closed after the context is done")
+	}
+}
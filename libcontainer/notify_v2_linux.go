@@ -1,33 +1,41 @@
 package libcontainer
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"time"
 	"unsafe"
 
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/cgroups/fs2"
 	"github.com/opencontainers/runc/libcontainer/cgroups/fscommon"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 )
 
-func registerMemoryEventV2(cgDir, evName, cgEvName string) (<-chan struct{}, error) {
+// watchMemoryEventsV2 calls onEvent every time the cgDir/evName file is
+// modified, until the cgroup has no more processes (as seen in the
+// cgDir/cgEvName file), after which it calls onExit.
+func watchMemoryEventsV2(cgDir, evName, cgEvName string, onEvent, onExit func()) error {
 	fd, err := unix.InotifyInit()
 	if err != nil {
-		return nil, fmt.Errorf("unable to init inotify: %w", err)
+		return fmt.Errorf("unable to init inotify: %w", err)
 	}
 	// watching oom kill
 	evFd, err := unix.InotifyAddWatch(fd, filepath.Join(cgDir, evName), unix.IN_MODIFY)
 	if err != nil {
 		unix.Close(fd)
-		return nil, fmt.Errorf("unable to add inotify watch: %w", err)
+		return fmt.Errorf("unable to add inotify watch: %w", err)
 	}
 	// Because no `unix.IN_DELETE|unix.IN_DELETE_SELF` event for cgroup file system, so watching all process exited
 	cgFd, err := unix.InotifyAddWatch(fd, filepath.Join(cgDir, cgEvName), unix.IN_MODIFY)
 	if err != nil {
 		unix.Close(fd)
-		return nil, fmt.Errorf("unable to add inotify watch: %w", err)
+		return fmt.Errorf("unable to add inotify watch: %w", err)
 	}
-	ch := make(chan struct{})
 	go func() {
 		var (
 			buffer [unix.SizeofInotifyEvent + unix.PathMax + 1]byte
@@ -35,7 +43,7 @@ func registerMemoryEventV2(cgDir, evName, cgEvName string) (<-chan struct{}, err
 		)
 		defer func() {
 			unix.Close(fd)
-			close(ch)
+			onExit()
 		}()
 
 		for {
@@ -57,10 +65,7 @@ func registerMemoryEventV2(cgDir, evName, cgEvName string) (<-chan struct{}, err
 				}
 				switch int(rawEvent.Wd) {
 				case evFd:
-					oom, err := fscommon.GetValueByKey(cgDir, evName, "oom_kill")
-					if err != nil || oom > 0 {
-						ch <- struct{}{}
-					}
+					onEvent()
 				case cgFd:
 					pids, err := fscommon.GetValueByKey(cgDir, cgEvName, "populated")
 					if err != nil || pids == 0 {
@@ -70,6 +75,20 @@ func registerMemoryEventV2(cgDir, evName, cgEvName string) (<-chan struct{}, err
 			}
 		}
 	}()
+	return nil
+}
+
+func registerMemoryEventV2(cgDir, evName, cgEvName string) (<-chan struct{}, error) {
+	ch := make(chan struct{})
+	onEvent := func() {
+		oom, err := fscommon.GetValueByKey(cgDir, evName, "oom_kill")
+		if err != nil || oom > 0 {
+			ch <- struct{}{}
+		}
+	}
+	if err := watchMemoryEventsV2(cgDir, evName, cgEvName, onEvent, func() { close(ch) }); err != nil {
+		return nil, err
+	}
 	return ch, nil
 }
 
@@ -78,3 +97,172 @@ func registerMemoryEventV2(cgDir, evName, cgEvName string) (<-chan struct{}, err
 func notifyOnOOMV2(path string) (<-chan struct{}, error) {
 	return registerMemoryEventV2(path, "memory.events", "cgroup.events")
 }
+
+// notifyMemoryEventsV2 returns a channel on which the memory event counters
+// are sent, first as they are, and then every time they change (counters
+// which are not received before the next change are replaced by the new
+// ones). The channel is closed once the cgroup has no more processes.
+func notifyMemoryEventsV2(path string) (<-chan cgroups.MemoryEvents, error) {
+	ev, err := fs2.GetMemoryEvents(path)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan cgroups.MemoryEvents, 1)
+	ch <- *ev
+	onEvent := func() {
+		ev, err := fs2.GetMemoryEvents(path)
+		if err != nil {
+			logrus.Warnf("unable to read memory events: %v", err)
+			return
+		}
+		// Drop the stale counters, if any, so that the send below does
+		// not block (this is the only sender).
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- *ev
+	}
+	if err := watchMemoryEventsV2(path, "memory.events", "cgroup.events", onEvent, func() { close(ch) }); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// PSITrigger is a pressure stall information trigger (see
+// Documentation/accounting/psi.rst in the kernel sources), which fires when
+// the tasks of the cgroup are stalled on a resource for at least Threshold
+// within a time Window.
+type PSITrigger struct {
+	// Resource is "cpu", "memory" or "io".
+	Resource string
+	// Full is whether all the non-idle tasks (rather than some tasks) have
+	// to be stalled.
+	Full bool
+	// Threshold is the stall time which makes the trigger fire.
+	Threshold time.Duration
+	// Window is the time window, between 500ms and 10s (a multiple of 2s
+	// without CAP_SYS_RESOURCE). The trigger fires at most once per window.
+	Window time.Duration
+}
+
+// memoryPressureTrigger returns the PSI trigger for the memory pressure level
+// of NotifyMemoryPressure with cgroup v2. Callers needing other thresholds
+// can use NotifyPressure instead.
+func memoryPressureTrigger(level PressureLevel) (PSITrigger, error) {
+	switch level {
+	case LowPressure:
+		return PSITrigger{Resource: "memory", Threshold: 100 * time.Millisecond, Window: 2 * time.Second}, nil
+	case MediumPressure:
+		return PSITrigger{Resource: "memory", Threshold: 500 * time.Millisecond, Window: 2 * time.Second}, nil
+	case CriticalPressure:
+		return PSITrigger{Resource: "memory", Full: true, Threshold: 500 * time.Millisecond, Window: 2 * time.Second}, nil
+	}
+	return PSITrigger{}, fmt.Errorf("invalid pressure level %d", level)
+}
+
+// Validate checks that the trigger is valid.
+func (t PSITrigger) Validate() error {
+	switch t.Resource {
+	case "cpu", "memory", "io":
+	default:
+		return fmt.Errorf("invalid PSI trigger resource %q", t.Resource)
+	}
+	if t.Window < 500*time.Millisecond || t.Window > 10*time.Second {
+		return fmt.Errorf("invalid PSI trigger window %s (must be between 500ms and 10s)", t.Window)
+	}
+	if t.Threshold <= 0 || t.Threshold > t.Window {
+		return fmt.Errorf("invalid PSI trigger threshold %s (must be positive and at most the window)", t.Threshold)
+	}
+	return nil
+}
+
+// String returns the trigger in the format of the pressure files,
+// that is, "<some|full> <threshold> <window>" (in microseconds).
+func (t PSITrigger) String() string {
+	kind := "some"
+	if t.Full {
+		kind = "full"
+	}
+	return fmt.Sprintf("%s %d %d", kind, t.Threshold.Microseconds(), t.Window.Microseconds())
+}
+
+// registerPSITrigger registers the PSI trigger t in the cgroup cgDir, and
+// returns a channel on which an event is sent every time it fires (events
+// which are not received before the next one are coalesced). The trigger is
+// unregistered and the channel is closed once ctx is done or the cgroup is
+// removed.
+func registerPSITrigger(ctx context.Context, cgDir string, t PSITrigger) (<-chan struct{}, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(cgDir, t.Resource+".pressure")
+	fd, err := unix.Open(path, unix.O_RDWR|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	// The trigger lasts as long as the file is open. The kernel expects
+	// the string to be NUL-terminated (it replaces the last byte with NUL).
+	if _, err := unix.Write(fd, append([]byte(t.String()), 0)); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("unable to register PSI trigger %q: %w", t, &os.PathError{Op: "write", Path: path, Err: err})
+	}
+	// stopFd wakes up the poll below once ctx is done.
+	stopFd, err := unix.Eventfd(0, unix.EFD_CLOEXEC)
+	if err != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("eventfd", err)
+	}
+	// The poll goroutine owns stopFd, and only closes it once the waker
+	// goroutine (which may write to it) has returned.
+	exited := make(chan struct{})
+	wakerDone := make(chan struct{})
+	go func() {
+		defer close(wakerDone)
+		select {
+		case <-ctx.Done():
+			var one [8]byte
+			one[0] = 1 // Native endianness does not matter for a non-zero value.
+			_, _ = unix.Write(stopFd, one[:])
+		case <-exited:
+		}
+	}()
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer func() {
+			close(exited)
+			<-wakerDone
+			unix.Close(fd)
+			unix.Close(stopFd)
+			close(ch)
+		}()
+		fds := []unix.PollFd{
+			{Fd: int32(fd), Events: unix.POLLPRI},
+			{Fd: int32(stopFd), Events: unix.POLLIN},
+		}
+		for {
+			if _, err := unix.Poll(fds, -1); err != nil {
+				if errors.Is(err, unix.EINTR) {
+					continue
+				}
+				logrus.Warnf("unable to poll PSI trigger: %v", err)
+				return
+			}
+			if fds[1].Revents != 0 {
+				return
+			}
+			// POLLERR is set once the cgroup is removed.
+			if fds[0].Revents&(unix.POLLERR|unix.POLLHUP|unix.POLLNVAL) != 0 {
+				return
+			}
+			if fds[0].Revents&unix.POLLPRI != 0 {
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
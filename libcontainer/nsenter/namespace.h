@@ -7,6 +7,9 @@
 #include <sched.h>
 
 /* All of these are taken from include/uapi/linux/sched.h */
+#ifndef CLONE_NEWTIME
+#	define CLONE_NEWTIME 0x00000080 /* New time namespace */
+#endif
 #ifndef CLONE_NEWNS
 #	define CLONE_NEWNS 0x00020000 /* New mount namespace group */
 #endif
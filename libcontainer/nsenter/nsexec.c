@@ -20,6 +20,7 @@
 #include <sys/ioctl.h>
 #include <sys/prctl.h>
 #include <sys/socket.h>
+#include <sys/syscall.h>
 #include <sys/types.h>
 #include <sys/wait.h>
 
@@ -32,6 +33,37 @@
 
 extern char *escape_json_string(char *str);
 
+/*
+ * The mount API syscalls, which old versions of glibc and of the kernel
+ * headers don't define. See include/uapi/linux/mount.h.
+ */
+#ifndef __NR_open_tree
+#	define __NR_open_tree 428
+#endif
+#ifndef __NR_mount_setattr
+#	define __NR_mount_setattr 442
+#endif
+#ifndef MOUNT_ATTR_IDMAP
+#	define MOUNT_ATTR_IDMAP 0x00100000
+#endif
+
+struct mount_attr_t {
+	uint64_t attr_set;
+	uint64_t attr_clr;
+	uint64_t propagation;
+	uint64_t userns_fd;
+};
+
+static int sys_open_tree(int dirfd, const char *path, unsigned int flags)
+{
+	return syscall(__NR_open_tree, dirfd, path, flags);
+}
+
+static int sys_mount_setattr(int dirfd, const char *path, unsigned int flags, struct mount_attr_t *attr)
+{
+	return syscall(__NR_mount_setattr, dirfd, path, flags, attr, sizeof(*attr));
+}
+
 /* Synchronisation values. */
 enum sync_t {
 	SYNC_USERMAP_PLS = 0x40,	/* Request parent to map our users. */
@@ -42,6 +74,8 @@ enum sync_t {
 	SYNC_CHILD_FINISH = 0x45,	/* The child or grandchild has finished. */
 	SYNC_MOUNTSOURCES_PLS = 0x46,	/* Tell parent to send mount sources by SCM_RIGHTS. */
 	SYNC_MOUNTSOURCES_ACK = 0x47,	/* All mount sources have been sent. */
+	SYNC_MOUNT_IDMAP_PLS = 0x48,	/* Tell parent to send id-mapped mounts by SCM_RIGHTS. */
+	SYNC_MOUNT_IDMAP_ACK = 0x49,	/* All id-mapped mounts have been sent. */
 };
 
 #define STAGE_SETUP  -1
@@ -94,6 +128,14 @@ struct nlconfig_t {
 	/* Mount sources opened outside the container userns. */
 	char *mountsources;
 	size_t mountsources_len;
+
+	/* Clock offsets of the new time namespace. */
+	char *timensoffset;
+	size_t timensoffset_len;
+
+	/* Id-mapped mounts created outside the container userns. */
+	char *idmapsources;
+	size_t idmapsources_len;
 };
 
 /*
@@ -127,6 +169,8 @@ static int loglevel = DEBUG;
 #define UIDMAPPATH_ATTR		27288
 #define GIDMAPPATH_ATTR		27289
 #define MOUNT_SOURCES_ATTR	27290
+#define TIMENSOFFSET_ATTR	27291
+#define IDMAP_SOURCES_ATTR	27292
 
 /*
  * Use the raw syscall for versions of glibc which don't include a function for
@@ -376,6 +420,21 @@ static void update_oom_score_adj(char *data, size_t len)
 		bail("failed to update /proc/self/oom_score_adj");
 }
 
+/*
+ * The clock offsets of a new time namespace can only be set before any
+ * process has entered it, and the process which has unshared it is not in
+ * it (only its children are), so this is done through /proc/self.
+ */
+static void update_timens_offsets(char *data, size_t len)
+{
+	if (data == NULL || len == 0)
+		return;
+
+	write_log(DEBUG, "update /proc/self/timens_offsets to '%s'", data);
+	if (write_file(data, strnlen(data, len), "/proc/self/timens_offsets") < 0)
+		bail("failed to update /proc/self/timens_offsets");
+}
+
 /* A dummy function that just jumps to the given jumpval. */
 static int child_func(void *arg) __attribute__((noinline));
 static int child_func(void *arg)
@@ -462,6 +521,8 @@ static int nsflag(char *name)
 		return CLONE_NEWUSER;
 	else if (!strcmp(name, "uts"))
 		return CLONE_NEWUTS;
+	else if (!strcmp(name, "time"))
+		return CLONE_NEWTIME;
 
 	/* If we don't recognise a name, fallback to 0. */
 	return 0;
@@ -552,6 +613,14 @@ static void nl_parse(int fd, struct nlconfig_t *config)
 			config->mountsources = current;
 			config->mountsources_len = payload_len;
 			break;
+		case TIMENSOFFSET_ATTR:
+			config->timensoffset = current;
+			config->timensoffset_len = payload_len;
+			break;
+		case IDMAP_SOURCES_ATTR:
+			config->idmapsources = current;
+			config->idmapsources_len = payload_len;
+			break;
 		default:
 			bail("unknown netlink message type %d", nlattr->nla_type);
 		}
@@ -738,26 +807,30 @@ void send_fd(int sockfd, int fd)
 		bail("failed to send fd %d via unix socket %d", fd, sockfd);
 }
 
-void receive_mountsources(int sockfd)
+/* Receive the fds listed in the fds_env env var, which are sent in order. */
+void receive_mountsources(int sockfd, const char *fds_env)
 {
 	char *mount_fds, *endp;
 	long new_fd;
 
 	// This env var must be a json array of ints.
-	mount_fds = getenv("_LIBCONTAINER_MOUNT_FDS");
+	mount_fds = getenv(fds_env);
+	if (mount_fds == NULL) {
+		bail("missing %s env var", fds_env);
+	}
 
 	if (mount_fds[0] != '[') {
-		bail("malformed _LIBCONTAINER_MOUNT_FDS env var: missing '['");
+		bail("malformed %s env var: missing '['", fds_env);
 	}
 	mount_fds++;
 
 	for (endp = mount_fds; *endp != ']'; mount_fds = endp + 1) {
 		new_fd = strtol(mount_fds, &endp, 10);
 		if (endp == mount_fds) {
-			bail("malformed _LIBCONTAINER_MOUNT_FDS env var: not a number");
+			bail("malformed %s env var: not a number", fds_env);
 		}
 		if (*endp == '\0') {
-			bail("malformed _LIBCONTAINER_MOUNT_FDS env var: missing ]");
+			bail("malformed %s env var: missing ]", fds_env);
 		}
 		// The list contains -1 when no fd is needed. Ignore them.
 		if (new_fd == -1) {
@@ -765,7 +838,7 @@ void receive_mountsources(int sockfd)
 		}
 
 		if (new_fd == LONG_MAX || new_fd < 0 || new_fd > INT_MAX) {
-			bail("malformed _LIBCONTAINER_MOUNT_FDS env var: fds out of range");
+			bail("malformed %s env var: fds out of range", fds_env);
 		}
 
 		receive_fd(sockfd, new_fd);
@@ -830,6 +903,109 @@ void send_mountsources(int sockfd, pid_t child, char *mountsources, size_t mount
 		bail("failed to close container mount namespace fd %d", container_mntns_fd);
 }
 
+/*
+ * Create the id-mapped mounts, and send them to the child. For each container
+ * mount, idmapsources has four NUL-terminated fields: the source (empty if the
+ * mount is not id-mapped), the path of the user namespace to map it to (empty
+ * for the user namespace of the child), and the open_tree(2) and
+ * mount_setattr(2) flags.
+ *
+ * This has to be done outside of the container user namespace, as setting
+ * MOUNT_ATTR_IDMAP requires CAP_SYS_ADMIN in the user namespace the mount
+ * is mapped to. The mounts are cloned in the container mount namespace, as
+ * the kernel only allows to attach a detached mount in the mount namespace
+ * it has been created from.
+ */
+void send_idmapsources(int sockfd, pid_t child, char *idmapsources, size_t idmapsources_len)
+{
+	char proc_path[PATH_MAX];
+	char child_userns_path[PATH_MAX];
+	char *idmapsources_end;
+	int host_mntns_fd;
+	int container_mntns_fd;
+
+	// container_linux.go bootstrapData() only sends the attribute if there
+	// are id-mapped mounts.
+	if (idmapsources == NULL)
+		return;
+
+	/* Skip the NUL terminator of the netlink attribute payload. */
+	idmapsources_end = idmapsources + idmapsources_len - 1;
+
+	if (snprintf(child_userns_path, PATH_MAX, "/proc/%d/ns/user", child) < 0)
+		bail("failed to get user namespace path");
+
+	host_mntns_fd = open("/proc/self/ns/mnt", O_RDONLY | O_CLOEXEC);
+	if (host_mntns_fd == -1)
+		bail("failed to get current mount namespace");
+
+	if (snprintf(proc_path, PATH_MAX, "/proc/%d/ns/mnt", child) < 0)
+		bail("failed to get mount namespace path");
+
+	container_mntns_fd = open(proc_path, O_RDONLY | O_CLOEXEC);
+	if (container_mntns_fd == -1)
+		bail("failed to get container mount namespace");
+
+	if (setns(container_mntns_fd, CLONE_NEWNS) < 0)
+		bail("failed to setns to container mntns");
+
+	while (idmapsources < idmapsources_end) {
+		char *fields[4];
+		struct mount_attr_t attr = { };
+		unsigned int tree_flags, attr_flags;
+		const char *userns_path;
+		int fd_tree, userns_fd;
+		int i;
+
+		for (i = 0; i < 4; i++) {
+			if (idmapsources >= idmapsources_end)
+				bail("malformed id-mapped mount sources");
+			fields[i] = idmapsources;
+			idmapsources += strlen(idmapsources) + 1;
+		}
+		if (fields[0][0] == '\0')
+			continue;
+
+		tree_flags = strtoul(fields[2], NULL, 10);
+		attr_flags = strtoul(fields[3], NULL, 10);
+		userns_path = fields[1][0] != '\0' ? fields[1] : child_userns_path;
+
+		write_log(DEBUG, "create id-mapped mount of %s with user namespace %s", fields[0], userns_path);
+		fd_tree = sys_open_tree(AT_FDCWD, fields[0], tree_flags);
+		if (fd_tree < 0)
+			bail("failed to open_tree %s", fields[0]);
+
+		userns_fd = open(userns_path, O_RDONLY | O_CLOEXEC);
+		if (userns_fd < 0)
+			bail("failed to open user namespace %s", userns_path);
+
+		attr.attr_set = MOUNT_ATTR_IDMAP;
+		attr.userns_fd = userns_fd;
+		if (sys_mount_setattr(fd_tree, "", attr_flags, &attr) < 0) {
+			if (errno == EINVAL)
+				bail("failed to set MOUNT_ATTR_IDMAP on %s (the filesystem may not support id-mapped mounts)",
+				     fields[0]);
+			bail("failed to set MOUNT_ATTR_IDMAP on %s", fields[0]);
+		}
+
+		if (close(userns_fd) < 0)
+			bail("failed to close user namespace fd %d", userns_fd);
+
+		send_fd(sockfd, fd_tree);
+
+		if (close(fd_tree) < 0)
+			bail("failed to close id-mapped mount fd %d", fd_tree);
+	}
+
+	if (setns(host_mntns_fd, CLONE_NEWNS) < 0)
+		bail("failed to setns to host mntns");
+
+	if (close(host_mntns_fd) < 0)
+		bail("failed to close host mount namespace fd %d", host_mntns_fd);
+	if (close(container_mntns_fd) < 0)
+		bail("failed to close container mount namespace fd %d", container_mntns_fd);
+}
+
 void nsexec(void)
 {
 	int pipenum;
@@ -1072,6 +1248,16 @@ void nsexec(void)
 						bail("failed to sync with child: write(SYNC_MOUNTSOURCES_ACK)");
 					}
 					break;
+				case SYNC_MOUNT_IDMAP_PLS:
+					send_idmapsources(syncfd, stage1_pid, config.idmapsources,
+							  config.idmapsources_len);
+
+					s = SYNC_MOUNT_IDMAP_ACK;
+					if (write(syncfd, &s, sizeof(s)) != sizeof(s)) {
+						sane_kill(stage1_pid, SIGKILL);
+						bail("failed to sync with child: write(SYNC_MOUNT_IDMAP_ACK)");
+					}
+					break;
 				case SYNC_CHILD_FINISH:
 					write_log(DEBUG, "stage-1 complete");
 					stage1_complete = true;
@@ -1226,6 +1412,10 @@ void nsexec(void)
 			if (unshare(config.cloneflags & ~CLONE_NEWCGROUP) < 0)
 				bail("failed to unshare remaining namespaces (except cgroupns)");
 
+			/* Set the clock offsets before stage-2 enters the time namespace. */
+			if (config.cloneflags & CLONE_NEWTIME)
+				update_timens_offsets(config.timensoffset, config.timensoffset_len);
+
 			/* Ask our parent to send the mount sources fds. */
 			if (config.mountsources) {
 				s = SYNC_MOUNTSOURCES_PLS;
@@ -1235,7 +1425,7 @@ void nsexec(void)
 				}
 
 				/* Receive and install all mount sources fds. */
-				receive_mountsources(syncfd);
+				receive_mountsources(syncfd, "_LIBCONTAINER_MOUNT_FDS");
 
 				/* Parent finished to send the mount sources fds. */
 				if (read(syncfd, &s, sizeof(s)) != sizeof(s)) {
@@ -1248,6 +1438,28 @@ void nsexec(void)
 				}
 			}
 
+			/* Ask our parent to send the id-mapped mounts fds. */
+			if (config.idmapsources) {
+				s = SYNC_MOUNT_IDMAP_PLS;
+				if (write(syncfd, &s, sizeof(s)) != sizeof(s)) {
+					sane_kill(stage2_pid, SIGKILL);
+					bail("failed to sync with parent: write(SYNC_MOUNT_IDMAP_PLS)");
+				}
+
+				/* Receive and install all id-mapped mounts fds. */
+				receive_mountsources(syncfd, "_LIBCONTAINER_IDMAP_FDS");
+
+				/* Parent finished to send the id-mapped mounts fds. */
+				if (read(syncfd, &s, sizeof(s)) != sizeof(s)) {
+					sane_kill(stage2_pid, SIGKILL);
+					bail("failed to sync with parent: read(SYNC_MOUNT_IDMAP_ACK)");
+				}
+				if (s != SYNC_MOUNT_IDMAP_ACK) {
+					sane_kill(stage2_pid, SIGKILL);
+					bail("failed to sync with parent: SYNC_MOUNT_IDMAP_ACK: got %u", s);
+				}
+			}
+
 			/*
 			 * TODO: What about non-namespace clone flags that we're dropping here?
 			 *
@@ -0,0 +1,232 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/system"
+)
+
+// pidHandle refers to a process using a pidfd, so that it can be signaled and
+// watched without races with pid reuse. On kernels without pidfd support, it
+// falls back to using the pid.
+type pidHandle struct {
+	pid int
+	// mu guards pidfd and done, so that the pidfd is not closed (and its
+	// number reused) while it is being used.
+	mu sync.RWMutex
+	// pidfd is -1 if pidfds are not supported.
+	pidfd int
+	// done is set once the process has been waited for.
+	done bool
+}
+
+// newPidHandle returns a handle for a child process of the caller, whose pid
+// can not be reused before it is waited for.
+func newPidHandle(pid int) (*pidHandle, error) {
+	fd, err := openPidfd(pid)
+	if err != nil {
+		return nil, err
+	}
+	return &pidHandle{pid: pid, pidfd: fd}, nil
+}
+
+// openPidHandle returns a handle for a process which might not be a child of
+// the caller, identified by its pid and start time. It returns
+// os.ErrProcessDone if the process has exited.
+func openPidHandle(pid int, startTime uint64) (*pidHandle, error) {
+	h, err := newPidHandle(pid)
+	if err != nil {
+		if errors.Is(err, unix.ESRCH) {
+			return nil, os.ErrProcessDone
+		}
+		return nil, err
+	}
+	// Now that the pidfd is open, make sure it refers to the right
+	// process, rather than to one which has reused its pid.
+	stat, err := system.Stat(pid)
+	if err != nil || stat.StartTime != startTime || stat.State == system.Zombie || stat.State == system.Dead {
+		h.close()
+		return nil, os.ErrProcessDone
+	}
+	return h, nil
+}
+
+func (h *pidHandle) signal(s unix.Signal) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.done {
+		return os.ErrProcessDone
+	}
+	var err error
+	if h.pidfd >= 0 {
+		err = unix.PidfdSendSignal(h.pidfd, s, nil, 0)
+	} else {
+		err = unix.Kill(h.pid, s)
+	}
+	if errors.Is(err, unix.ESRCH) {
+		return os.ErrProcessDone
+	}
+	if err != nil && h.pidfd >= 0 {
+		return os.NewSyscallError("pidfd_send_signal", err)
+	}
+	return err
+}
+
+// running reports whether the process has not exited yet. Without pidfd
+// support, a zombie process is reported as running.
+func (h *pidHandle) running() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.done {
+		return false
+	}
+	if h.pidfd < 0 {
+		return unix.Kill(h.pid, 0) == nil
+	}
+	exited, err := pidfdExited(h.pidfd, 0)
+	return err == nil && !exited
+}
+
+// exited reports whether the process is known to have exited, that is, it
+// has been waited for, or its pidfd tells so. h may be nil.
+func (h *pidHandle) exited() bool {
+	if h == nil {
+		return false
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.done {
+		return true
+	}
+	if h.pidfd < 0 {
+		return false
+	}
+	exited, err := pidfdExited(h.pidfd, 0)
+	return err == nil && exited
+}
+
+// waitExited blocks until the process has exited, without reaping it. It
+// returns immediately if pidfds are not supported.
+func (h *pidHandle) waitExited() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.done || h.pidfd < 0 {
+		return nil
+	}
+	_, err := pidfdExited(h.pidfd, -1)
+	return err
+}
+
+// dupFd returns a duplicate of the pidfd, which the caller must close, or -1
+// if h is nil, pidfds are not supported, or the process has been waited for.
+func (h *pidHandle) dupFd() (int, error) {
+	if h == nil {
+		return -1, nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.pidfd < 0 {
+		return -1, nil
+	}
+	fd, err := unix.FcntlInt(uintptr(h.pidfd), unix.F_DUPFD_CLOEXEC, 0)
+	if err != nil {
+		return -1, os.NewSyscallError("fcntl", err)
+	}
+	return fd, nil
+}
+
+// close releases the pidfd. It must be called once the process is waited
+// for. It waits for the pidfd to be no longer in use.
+func (h *pidHandle) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.done = true
+	if h.pidfd >= 0 {
+		_ = unix.Close(h.pidfd)
+		h.pidfd = -1
+	}
+}
+
+// openPidfd returns a pidfd referring to the process with the given pid. It
+// returns -1 (and no error) if pidfds are not supported by the kernel
+// (before Linux 5.3).
+func openPidfd(pid int) (int, error) {
+	fd, err := unix.PidfdOpen(pid, unix.PIDFD_NONBLOCK)
+	if errors.Is(err, unix.EINVAL) {
+		// PIDFD_NONBLOCK is only supported since Linux 5.10, and is
+		// not needed to poll the pidfd.
+		fd, err = unix.PidfdOpen(pid, 0)
+	}
+	if err != nil {
+		if errors.Is(err, unix.ENOSYS) {
+			return -1, nil
+		}
+		return -1, os.NewSyscallError("pidfd_open", err)
+	}
+	unix.CloseOnExec(fd)
+	return fd, nil
+}
+
+// pidfdExited waits for at most timeout (or indefinitely, if it is negative)
+// for the process referred to by pidfd to exit (the pidfd becomes readable
+// once the process is a zombie).
+func pidfdExited(pidfd int, timeout time.Duration) (bool, error) {
+	fds := []unix.PollFd{{Fd: int32(pidfd), Events: unix.POLLIN}}
+	ms := -1
+	if timeout >= 0 {
+		ms = int(timeout.Milliseconds())
+	}
+	for {
+		n, err := unix.Poll(fds, ms)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return false, os.NewSyscallError("poll", err)
+		}
+		if n > 0 && fds[0].Revents&unix.POLLNVAL != 0 {
+			return false, os.NewSyscallError("poll", unix.EBADF)
+		}
+		return n > 0, nil
+	}
+}
+
+// waitExit waits until the process referred to by pidfd has exited (as
+// reported by exited, if pidfd is -1), timeout has passed, or ctx is done.
+// It returns whether the process has exited.
+func waitExit(ctx context.Context, pidfd int, exited func() bool, timeout time.Duration) (bool, error) {
+	const interval = 100 * time.Millisecond
+	deadline := time.Now().Add(timeout)
+	for {
+		wait := time.Until(deadline)
+		if wait > interval {
+			wait = interval
+		}
+		if wait < 0 {
+			wait = 0
+		}
+		if pidfd >= 0 {
+			done, err := pidfdExited(pidfd, wait)
+			if err != nil || done {
+				return done, err
+			}
+		} else {
+			time.Sleep(wait)
+			if exited() {
+				return true, nil
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		if !time.Now().Before(deadline) {
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/system"
+)
+
+func TestWaitExit(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill() //nolint:errcheck
+
+	pidfd, err := openPidfd(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pidfd < 0 {
+		t.Skip("pidfd is not supported")
+	}
+	defer unix.Close(pidfd)
+
+	done, err := waitExit(context.Background(), pidfd, nil, 200*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done {
+		t.Fatal("expected the process to be running")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := waitExit(ctx, pidfd, nil, time.Second); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+
+	if err := unix.PidfdSendSignal(pidfd, unix.SIGKILL, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	done, err = waitExit(context.Background(), pidfd, nil, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !done {
+		t.Fatal("expected the process to have exited")
+	}
+}
+
+func TestWaitExitNoPidfd(t *testing.T) {
+	calls := 0
+	exited := func() bool {
+		calls++
+		return calls == 3
+	}
+	done, err := waitExit(context.Background(), -1, exited, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !done || calls != 3 {
+		t.Fatalf("expected the process to have exited after 3 checks, got %d", calls)
+	}
+}
+
+func TestPidHandle(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill() //nolint:errcheck
+
+	h, err := newPidHandle(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.close()
+	if !h.running() {
+		t.Fatal("expected the process to be running")
+	}
+
+	stat, err := system.Stat(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openPidHandle(cmd.Process.Pid, stat.StartTime+1); !errors.Is(err, os.ErrProcessDone) {
+		t.Fatalf("expected %v for a wrong start time, got %v", os.ErrProcessDone, err)
+	}
+	h2, err := openPidHandle(cmd.Process.Pid, stat.StartTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2.close()
+
+	fd, err := h.dupFd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fd >= 0 {
+		defer unix.Close(fd) //nolint:errcheck
+	}
+
+	// The handle can be used while the process is waited for.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for h.running() {
+			_ = h.signal(0)
+		}
+	}()
+	if err := h.signal(unix.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitProcess(cmd, h); err == nil {
+		t.Fatal("expected the process to be killed")
+	}
+	<-done
+	if h.running() || !h.exited() {
+		t.Fatal("expected the process to have exited")
+	}
+	if fd >= 0 {
+		// The duplicate is still usable once the handle is closed.
+		if exited, err := pidfdExited(fd, 0); err != nil || !exited {
+			t.Fatalf("expected the duplicate pidfd to report the exit, got %v, %v", exited, err)
+		}
+	}
+	if err := h.signal(unix.SIGKILL); !errors.Is(err, os.ErrProcessDone) {
+		t.Fatalf("expected %v, got %v", os.ErrProcessDone, err)
+	}
+}
@@ -15,6 +15,7 @@ type processOperations interface {
 	wait() (*os.ProcessState, error)
 	signal(sig os.Signal) error
 	pid() int
+	pidfd() (int, error)
 }
 
 // Process specifies the configuration and IO for a process inside
@@ -71,6 +72,23 @@ type Process struct {
 	// If Rlimits are not set, the container will inherit rlimits from the parent process
 	Rlimits []configs.Rlimit
 
+	// Scheduler specifies the scheduling policy and attributes to set for the
+	// process. If it is not set, the one from the container config is used.
+	Scheduler *configs.Scheduler
+
+	// IOPriority specifies the I/O scheduling class and priority to set for
+	// the process. If it is not set, the one from the container config is used.
+	IOPriority *configs.IOPriority
+
+	// CPUAffinity specifies the CPU affinity of the process, while it is set
+	// up and once it is executed. If it is not set, the one from the container
+	// config is used.
+	CPUAffinity *configs.CPUAffinity
+
+	// Landlock specifies a Landlock ruleset to restrict the process with.
+	// If it is not set, the one from the container config is used.
+	Landlock *configs.Landlock
+
 	// ConsoleSocket provides the masterfd console.
 	ConsoleSocket *os.File
 
@@ -110,6 +128,17 @@ func (p Process) Pid() (int, error) {
 	return p.ops.pid(), nil
 }
 
+// Pidfd returns a new pidfd referring to the process, which can be used to
+// signal or poll it without races with pid reuse. The caller owns the pidfd,
+// and must close it. It returns -1 if pidfds are not supported by the kernel
+// (before Linux 5.3), or the process has already been waited for.
+func (p Process) Pidfd() (int, error) {
+	if p.ops == nil {
+		return -1, errInvalidProcess
+	}
+	return p.ops.pidfd()
+}
+
 // Signal sends a signal to the Process.
 func (p Process) Signal(sig os.Signal) error {
 	if p.ops == nil {
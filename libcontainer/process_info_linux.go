@@ -0,0 +1,122 @@
+package libcontainer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/system"
+	"github.com/opencontainers/runc/libcontainer/user"
+)
+
+// ProcessInfo describes a process of a container, as read from /proc (see
+// Container.ListProcesses).
+type ProcessInfo struct {
+	// Pid is the pid of the process, and PPid the pid of its parent, as
+	// seen by the caller.
+	Pid  int `json:"pid"`
+	PPid int `json:"ppid"`
+	// NsPid is the pid of the process in its own pid namespace, or 0 if it
+	// is unknown (before Linux 4.1).
+	NsPid int `json:"ns_pid,omitempty"`
+	// UID is the effective user ID of the process, and User the matching
+	// user name on the host, if any.
+	UID  int    `json:"uid"`
+	User string `json:"user,omitempty"`
+	// State is the state of the process, as a single character (see
+	// proc(5)).
+	State string `json:"state"`
+	// RSS is the resident set size of the process, in bytes.
+	RSS uint64 `json:"rss"`
+	// CPUTime is the time the process has been scheduled in user and
+	// kernel mode.
+	CPUTime time.Duration `json:"cpu_time"`
+	// Name is the command name of the process, and Cmdline its command
+	// line, which is empty for zombie processes.
+	Name    string   `json:"name"`
+	Cmdline []string `json:"cmdline"`
+	// Cgroup is the path of the cgroup of the process, relative to the
+	// container's cgroup ("/" being the container's cgroup itself).
+	Cgroup string `json:"cgroup"`
+}
+
+// clockTicks is the number of clock ticks per second, in which the process
+// times are reported. It is always 100 on Linux, see sysconf(_SC_CLK_TCK).
+const clockTicks = 100
+
+// readProcessInfo reads the information about the process pid from /proc.
+// The Cgroup and User fields are left empty.
+func readProcessInfo(pid int) (*ProcessInfo, error) {
+	stat, err := system.Stat(pid)
+	if err != nil {
+		return nil, err
+	}
+	p := &ProcessInfo{
+		Pid:     pid,
+		PPid:    stat.PPid,
+		State:   string(stat.State),
+		RSS:     stat.RSS * uint64(os.Getpagesize()),
+		CPUTime: time.Duration(stat.UTime+stat.STime) * time.Second / clockTicks,
+		Name:    stat.Name,
+	}
+	dir := "/proc/" + strconv.Itoa(pid)
+	if err := p.readStatus(dir + "/status"); err != nil {
+		return nil, err
+	}
+	cmdline, err := os.ReadFile(dir + "/cmdline")
+	if err != nil {
+		return nil, err
+	}
+	cmdline = bytes.TrimSuffix(cmdline, []byte{0})
+	if len(cmdline) > 0 {
+		p.Cmdline = strings.Split(string(cmdline), "\x00")
+	}
+	return p, nil
+}
+
+// readStatus sets the fields of p found in /proc/<pid>/status.
+func (p *ProcessInfo) readStatus(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		key, val, ok := strings.Cut(s.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(val)
+		switch {
+		case key == "Uid" && len(fields) > 1:
+			// Real, effective, saved set, and filesystem UIDs.
+			if p.UID, err = strconv.Atoi(fields[1]); err != nil {
+				return fmt.Errorf("invalid Uid in %s: %w", path, err)
+			}
+		case key == "NSpid" && len(fields) > 0:
+			// Pids in each of the nested pid namespaces of the process.
+			if p.NsPid, err = strconv.Atoi(fields[len(fields)-1]); err != nil {
+				return fmt.Errorf("invalid NSpid in %s: %w", path, err)
+			}
+		}
+	}
+	return s.Err()
+}
+
+// lookupUserName returns the name of the user uid on the host, or "" if
+// there is none, caching the result in names.
+func lookupUserName(names map[int]string, uid int) string {
+	name, ok := names[uid]
+	if !ok {
+		if u, err := user.LookupUid(uid); err == nil {
+			name = u.Name
+		}
+		names[uid] = name
+	}
+	return name
+}
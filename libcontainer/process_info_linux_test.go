@@ -0,0 +1,28 @@
+package libcontainer
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadProcessInfo(t *testing.T) {
+	p, err := readProcessInfo(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Pid != os.Getpid() || p.PPid != os.Getppid() {
+		t.Errorf("expected pid %d and ppid %d, got %d and %d", os.Getpid(), os.Getppid(), p.Pid, p.PPid)
+	}
+	if p.UID != os.Geteuid() {
+		t.Errorf("expected uid %d, got %d", os.Geteuid(), p.UID)
+	}
+	if p.State == "" {
+		t.Error("expected a state")
+	}
+	if p.RSS == 0 {
+		t.Error("expected a non-zero RSS")
+	}
+	if len(p.Cmdline) == 0 || p.Cmdline[0] != os.Args[0] {
+		t.Errorf("expected cmdline %q, got %q", os.Args, p.Cmdline)
+	}
+}
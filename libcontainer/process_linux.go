@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
@@ -63,6 +64,8 @@ type setnsProcess struct {
 	process         *Process
 	bootstrapData   io.Reader
 	initProcessPid  int
+	handle          *pidHandle
+	container       *Container
 }
 
 func (p *setnsProcess) startTime() (uint64, error) {
@@ -71,18 +74,14 @@ func (p *setnsProcess) startTime() (uint64, error) {
 }
 
 func (p *setnsProcess) signal(sig os.Signal) error {
-	s, ok := sig.(unix.Signal)
-	if !ok {
-		return errors.New("os: unsupported signal type")
-	}
-	return unix.Kill(p.pid(), s)
+	return signalProcess(p.handle, p.pid(), sig)
 }
 
 func (p *setnsProcess) start() (retErr error) {
 	defer p.messageSockPair.parent.Close()
 	// get the "before" value of oom kill count
 	oom, _ := p.manager.OOMKillCount()
-	err := p.cmd.Start()
+	err := startWithCPUAffinity(p.cmd, p.config.CPUAffinity)
 	// close the write-side of the pipes (controlled by child)
 	p.messageSockPair.child.Close()
 	p.logFilePair.child.Close()
@@ -143,6 +142,10 @@ func (p *setnsProcess) start() (retErr error) {
 			}
 		}
 	}
+	// Joining the cpuset cgroup has reset the CPU affinity.
+	if err := setInitialCPUAffinity(p.pid(), p.config.CPUAffinity); err != nil {
+		return err
+	}
 	if p.intelRdtPath != "" {
 		// if Intel RDT "resource control" filesystem path exists
 		_, err := os.Stat(p.intelRdtPath)
@@ -253,6 +256,9 @@ func (p *setnsProcess) execSetns() error {
 		return err
 	}
 	p.cmd.Process = process
+	if p.handle, err = newPidHandle(pid.Pid); err != nil {
+		return err
+	}
 	p.process.ops = p
 	return nil
 }
@@ -271,7 +277,14 @@ func (p *setnsProcess) terminate() error {
 }
 
 func (p *setnsProcess) wait() (*os.ProcessState, error) {
-	err := p.cmd.Wait()
+	err := waitProcess(p.cmd, p.handle)
+	// As for init, ProcessState is nil if the process was reaped by
+	// someone else (see RecordExecExit).
+	if ps := p.cmd.ProcessState; ps != nil && p.container != nil {
+		if ws, ok := ps.Sys().(syscall.WaitStatus); ok {
+			p.container.RecordExecExit(p.pid(), unix.WaitStatus(ws))
+		}
+	}
 
 	// Return actual ProcessState even on Wait error
 	return p.cmd.ProcessState, err
@@ -281,6 +294,10 @@ func (p *setnsProcess) pid() int {
 	return p.cmd.Process.Pid
 }
 
+func (p *setnsProcess) pidfd() (int, error) {
+	return p.handle.dupFd()
+}
+
 func (p *setnsProcess) externalDescriptors() []string {
 	return p.fds
 }
@@ -305,12 +322,20 @@ type initProcess struct {
 	process         *Process
 	bootstrapData   io.Reader
 	sharePidns      bool
+	handle          *pidHandle
+	// idmapUserns are the user namespaces of the id-mapped mounts, passed
+	// to cmd, which are only needed until it is started.
+	idmapUserns []*os.File
 }
 
 func (p *initProcess) pid() int {
 	return p.cmd.Process.Pid
 }
 
+func (p *initProcess) pidfd() (int, error) {
+	return p.handle.dupFd()
+}
+
 func (p *initProcess) externalDescriptors() []string {
 	return p.fds
 }
@@ -349,17 +374,23 @@ func (p *initProcess) waitForChildExit(childPid int) error {
 		return err
 	}
 	p.cmd.Process = process
+	if p.handle, err = newPidHandle(childPid); err != nil {
+		return err
+	}
 	p.process.ops = p
 	return nil
 }
 
 func (p *initProcess) start() (retErr error) {
 	defer p.messageSockPair.parent.Close() //nolint: errcheck
-	err := p.cmd.Start()
+	err := startWithCPUAffinity(p.cmd, p.config.CPUAffinity)
 	p.process.ops = p
 	// close the write-side of the pipes (controlled by child)
 	_ = p.messageSockPair.child.Close()
 	_ = p.logFilePair.child.Close()
+	for _, f := range p.idmapUserns {
+		_ = f.Close()
+	}
 	if err != nil {
 		p.process.ops = nil
 		return fmt.Errorf("unable to start init: %w", err)
@@ -411,6 +442,10 @@ func (p *initProcess) start() (retErr error) {
 	if err := p.manager.Apply(p.pid()); err != nil {
 		return fmt.Errorf("unable to apply cgroup configuration: %w", err)
 	}
+	// Joining the cpuset cgroup has reset the CPU affinity.
+	if err := setInitialCPUAffinity(p.pid(), p.config.CPUAffinity); err != nil {
+		return err
+	}
 	if p.intelRdtManager != nil {
 		if err := p.intelRdtManager.Apply(p.pid()); err != nil {
 			return fmt.Errorf("unable to apply Intel RDT configuration: %w", err)
@@ -551,6 +586,7 @@ func (p *initProcess) start() (retErr error) {
 				return fmt.Errorf("unable to store init state: %w", err)
 			}
 			p.container.initProcessStartTime = state.InitProcessStartTime
+			p.container.recordEvent(EventCreated, p.pid(), nil)
 
 			// Sync with child.
 			if err := writeSync(p.messageSockPair.parent, procRun); err != nil {
@@ -615,7 +651,16 @@ func (p *initProcess) start() (retErr error) {
 }
 
 func (p *initProcess) wait() (*os.ProcessState, error) {
-	err := p.cmd.Wait()
+	err := waitProcess(p.cmd, p.handle)
+	// ProcessState is nil if the process was reaped by someone else, in
+	// which case it's up to them to record the exit (see RecordExit).
+	if ps := p.cmd.ProcessState; ps != nil {
+		if ws, ok := ps.Sys().(syscall.WaitStatus); ok {
+			if err := p.container.saveExitStatus(unix.WaitStatus(ws)); err != nil {
+				logrus.WithError(err).Warn("unable to save init exit status")
+			}
+		}
+	}
 	// we should kill all processes in cgroup when init is died if we use host PID namespace
 	if p.sharePidns {
 		_ = signalAllProcesses(p.manager, unix.SIGKILL)
@@ -674,11 +719,7 @@ func (p *initProcess) createNetworkInterfaces() error {
 }
 
 func (p *initProcess) signal(sig os.Signal) error {
-	s, ok := sig.(unix.Signal)
-	if !ok {
-		return errors.New("os: unsupported signal type")
-	}
-	return unix.Kill(p.pid(), s)
+	return signalProcess(p.handle, p.pid(), sig)
 }
 
 func (p *initProcess) setExternalDescriptors(newFds []string) {
@@ -689,6 +730,81 @@ func (p *initProcess) forwardChildLogs() chan error {
 	return logs.ForwardLogs(p.logFilePair.parent)
 }
 
+// startWithCPUAffinity starts cmd and sets the initial CPU affinity of aff
+// (if any) on it. This is done before runc init is sent its bootstrap data,
+// so that the processes it creates inherit the affinity.
+func startWithCPUAffinity(cmd *exec.Cmd, aff *configs.CPUAffinity) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := setInitialCPUAffinity(cmd.Process.Pid, aff); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+	return nil
+}
+
+// setInitialCPUAffinity sets the initial CPU affinity of aff (if any) on all
+// the threads of the process pid.
+func setInitialCPUAffinity(pid int, aff *configs.CPUAffinity) error {
+	if aff == nil || aff.Initial == nil {
+		return nil
+	}
+	tasks, err := os.ReadDir("/proc/" + strconv.Itoa(pid) + "/task")
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		tid, err := strconv.Atoi(t.Name())
+		if err != nil {
+			continue
+		}
+		// The thread may have exited already.
+		if err := unix.SchedSetaffinity(tid, aff.Initial); err != nil && !errors.Is(err, unix.ESRCH) {
+			return fmt.Errorf("error setting initial CPU affinity: %w", err)
+		}
+	}
+	return nil
+}
+
+// initPidHandle returns the pid handle of a container's init process, or nil
+// if it was not started by us.
+func initPidHandle(p parentProcess) *pidHandle {
+	switch p := p.(type) {
+	case *initProcess:
+		return p.handle
+	case *restoredProcess:
+		return p.handle
+	}
+	return nil
+}
+
+// signalProcess sends sig to the process referred to by h or, if h is nil
+// (the process has not been fully started yet), by pid.
+func signalProcess(h *pidHandle, pid int, sig os.Signal) error {
+	s, ok := sig.(unix.Signal)
+	if !ok {
+		return errors.New("os: unsupported signal type")
+	}
+	if h != nil {
+		return h.signal(s)
+	}
+	return unix.Kill(pid, s)
+}
+
+// waitProcess waits for the process referred to by h to exit (see
+// pidHandle.waitExited), then reaps it by waiting for cmd. h may be nil.
+func waitProcess(cmd *exec.Cmd, h *pidHandle) error {
+	if h != nil {
+		if err := h.waitExited(); err != nil {
+			logrus.WithError(err).Debug("unable to wait on pidfd")
+		}
+		defer h.close()
+	}
+	return cmd.Wait()
+}
+
 func recvSeccompFd(childPid, childFd uintptr) (int, error) {
 	pidfd, _, errno := unix.Syscall(unix.SYS_PIDFD_OPEN, childPid, 0, 0)
 	if errno != 0 {
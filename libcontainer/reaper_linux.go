@@ -0,0 +1,128 @@
+package libcontainer
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/opencontainers/selinux/go-selinux"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/apparmor"
+)
+
+// runReaper is used instead of exec-ing the container process when
+// Config.Init is set. It starts the container process as a child of runc
+// init, and then stays as the container's init process, reaping zombies and
+// forwarding signals to the container process until it exits (see reap). It
+// only returns if the container process could not be started.
+//
+// execFifo is the exec fifo opened for writing, which is otherwise closed by
+// the exec of the container process, letting runc start return.
+func runReaper(name string, config *initConfig, execFifo int) error {
+	signals := reaperSignals()
+	files := []*os.File{os.Stdin, os.Stdout, os.Stderr}
+	for i := 0; i < config.PassedFilesCount; i++ {
+		files = append(files, os.NewFile(uintptr(stdioFdCount+i), "passed fd "+strconv.Itoa(i)))
+	}
+	attr := &os.ProcAttr{
+		Env:   os.Environ(),
+		Files: files,
+		Sys:   &unix.SysProcAttr{},
+	}
+	if config.CreateConsole {
+		// Put the container process in the foreground process group of the
+		// terminal, so that the signals generated by the terminal are sent
+		// to it rather than to us.
+		attr.Sys.Foreground = true
+		attr.Sys.Ctty = 0
+	}
+	p, err := os.StartProcess(name, config.Args, attr)
+	if err != nil {
+		signal.Stop(signals)
+		return err
+	}
+	_ = unix.Close(execFifo)
+	// The passed fds are only meant for the container process.
+	for _, f := range files[stdioFdCount:] {
+		_ = f.Close()
+	}
+	os.Exit(reap(p.Pid, signals))
+	return nil
+}
+
+// confineReaper applies the SELinux process label and the AppArmor profile
+// of the container to the current task, as runc init is not exec-ed when
+// Config.Init is set (see runReaper). As both are per-thread attributes, only
+// the thread which runs runc init (see init.go), and the threads it creates
+// later, are confined; this is the thread which reaps and forwards signals.
+//
+// For SELinux, this requires the policy to allow the runtime domain to
+// dyntransition to the process label and, as runc init is multi-threaded,
+// the process label type to be bounded by the runtime one (see typebounds).
+// For AppArmor, the container process inherits the profile rather than
+// changing to it on exec, so the profile must allow executing it.
+func confineReaper(config *initConfig) error {
+	if config.ProcessLabel != "" {
+		if err := selinux.SetTaskLabel(config.ProcessLabel); err != nil {
+			return fmt.Errorf("can't set init process label: %w", err)
+		}
+	}
+	if err := apparmor.ChangeProfile(config.AppArmorProfile); err != nil {
+		return fmt.Errorf("unable to change apparmor profile: %w", err)
+	}
+	return nil
+}
+
+// reaperSignals returns a channel on which all the signals we receive are
+// sent, to be passed to reap. It must be called before the container process
+// is started, so that a signal sent to us in the meantime is forwarded,
+// rather than killing us (and the whole container) by its default action.
+func reaperSignals() chan os.Signal {
+	signals := make(chan os.Signal, 128)
+	signal.Notify(signals)
+	return signals
+}
+
+// reap reaps the zombie processes of the container, and forwards the
+// signals received on signals (see reaperSignals) to the container process,
+// until the container process exits. It returns the exit code of the
+// container process or, if it was killed by a signal, 128 plus the signal
+// number (as the container's init can not be killed by a signal sent from
+// inside its pid namespace).
+func reap(pid int, signals chan os.Signal) int {
+	// Orphaned processes are reparented to us if we are pid 1. Otherwise
+	// (if the container shares the pid namespace of its parent), become
+	// their subreaper.
+	_ = unix.Prctl(unix.PR_SET_CHILD_SUBREAPER, 1, 0, 0, 0)
+
+	defer signal.Stop(signals)
+	for {
+		// Reap first, as the container process may have exited before
+		// we started to watch for SIGCHLD.
+		for {
+			var ws unix.WaitStatus
+			wpid, err := unix.Wait4(-1, &ws, unix.WNOHANG, nil)
+			if err == unix.EINTR { //nolint:errorlint // unix errors are bare
+				continue
+			}
+			if err != nil || wpid <= 0 {
+				break
+			}
+			if wpid == pid {
+				if ws.Signaled() {
+					return 128 + int(ws.Signal())
+				}
+				return ws.ExitStatus()
+			}
+		}
+		switch s := <-signals; s {
+		case unix.SIGCHLD:
+		case unix.SIGURG:
+			// Used by the Go runtime for goroutine preemption.
+		default:
+			_ = unix.Kill(pid, s.(unix.Signal))
+		}
+	}
+}
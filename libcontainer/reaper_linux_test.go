@@ -0,0 +1,81 @@
+package libcontainer
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// reap makes the calling process a subreaper and handles all the signals, so
+// it is run in a re-executed test binary (see init) rather than in the test
+// binary itself.
+func init() {
+	if os.Args[0] != "reaper-test" {
+		return
+	}
+	signals := reaperSignals()
+	name, err := exec.LookPath(os.Args[1])
+	if err != nil {
+		os.Exit(255)
+	}
+	p, err := os.StartProcess(name, os.Args[1:], &os.ProcAttr{
+		Files: []*os.File{os.Stdin, os.Stdout, os.Stderr},
+	})
+	if err != nil {
+		os.Exit(255)
+	}
+	os.Exit(reap(p.Pid, signals))
+}
+
+func reaperCommand(args ...string) *exec.Cmd {
+	return &exec.Cmd{
+		Path: os.Args[0],
+		Args: append([]string{"reaper-test"}, args...),
+	}
+}
+
+func reaperExitCode(t *testing.T, err error) int {
+	t.Helper()
+	var exitErr *exec.ExitError
+	if err == nil {
+		return 0
+	}
+	if !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+	return exitErr.ExitCode()
+}
+
+func TestReap(t *testing.T) {
+	cmd := reaperCommand("sh", "-c", "exit 3")
+	if code := reaperExitCode(t, cmd.Run()); code != 3 {
+		t.Fatalf("expected exit code 3, got %d", code)
+	}
+}
+
+func TestReapForwardsSignals(t *testing.T) {
+	cmd := reaperCommand("sh", "-c", "echo ready; exec sleep 10")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		t.Fatal(err)
+	}
+	if err := cmd.Process.Signal(unix.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	expected := 128 + int(unix.SIGTERM)
+	if code := reaperExitCode(t, cmd.Wait()); code != expected {
+		t.Fatalf("expected exit code %d, got %d", expected, code)
+	}
+}
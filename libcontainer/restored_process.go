@@ -15,10 +15,15 @@ func newRestoredProcess(cmd *exec.Cmd, fds []string) (*restoredProcess, error) {
 	if err != nil {
 		return nil, err
 	}
+	handle, err := newPidHandle(pid)
+	if err != nil {
+		return nil, err
+	}
 	return &restoredProcess{
 		cmd:              cmd,
 		processStartTime: stat.StartTime,
 		fds:              fds,
+		handle:           handle,
 	}, nil
 }
 
@@ -26,6 +31,7 @@ type restoredProcess struct {
 	cmd              *exec.Cmd
 	processStartTime uint64
 	fds              []string
+	handle           *pidHandle
 }
 
 func (p *restoredProcess) start() error {
@@ -36,6 +42,10 @@ func (p *restoredProcess) pid() int {
 	return p.cmd.Process.Pid
 }
 
+func (p *restoredProcess) pidfd() (int, error) {
+	return p.handle.dupFd()
+}
+
 func (p *restoredProcess) terminate() error {
 	err := p.cmd.Process.Kill()
 	if _, werr := p.wait(); err == nil {
@@ -47,7 +57,7 @@ func (p *restoredProcess) terminate() error {
 func (p *restoredProcess) wait() (*os.ProcessState, error) {
 	// TODO: how do we wait on the actual process?
 	// maybe use --exec-cmd in criu
-	err := p.cmd.Wait()
+	err := waitProcess(p.cmd, p.handle)
 	if err != nil {
 		var exitErr *exec.ExitError
 		if !errors.As(err, &exitErr) {
@@ -63,7 +73,7 @@ func (p *restoredProcess) startTime() (uint64, error) {
 }
 
 func (p *restoredProcess) signal(s os.Signal) error {
-	return p.cmd.Process.Signal(s)
+	return signalProcess(p.handle, p.pid(), s)
 }
 
 func (p *restoredProcess) externalDescriptors() []string {
@@ -107,12 +117,15 @@ func (p *nonChildProcess) startTime() (uint64, error) {
 	return p.processStartTime, nil
 }
 
+// signal sends s to the process using a pidfd, after making sure it has not
+// exited yet (so that its pid can not have been reused).
 func (p *nonChildProcess) signal(s os.Signal) error {
-	proc, err := os.FindProcess(p.processPid)
+	h, err := openPidHandle(p.processPid, p.processStartTime)
 	if err != nil {
 		return err
 	}
-	return proc.Signal(s)
+	defer h.close()
+	return signalProcess(h, p.processPid, s)
 }
 
 func (p *nonChildProcess) externalDescriptors() []string {
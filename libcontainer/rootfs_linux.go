@@ -34,7 +34,9 @@ type mountConfig struct {
 	cgroup2Path     string
 	rootlessCgroups bool
 	cgroupns        bool
+	procfs          *configs.Procfs
 	fd              *int
+	idmapFd         *int
 }
 
 // needsSetupDev returns true if /dev needs to be set up.
@@ -50,14 +52,17 @@ func needsSetupDev(config *configs.Config) bool {
 // prepareRootfs sets up the devices, mount points, and filesystems for use
 // inside a new mount namespace. It doesn't set anything as ro. You must call
 // finalizeRootfs after this function to finish setting up the rootfs.
-func prepareRootfs(pipe io.ReadWriter, iConfig *initConfig, mountFds []int) (err error) {
+func prepareRootfs(pipe io.ReadWriter, iConfig *initConfig, mountFds mountFds) (err error) {
 	config := iConfig.Config
 	if err := prepareRoot(config); err != nil {
 		return fmt.Errorf("error preparing rootfs: %w", err)
 	}
 
-	if mountFds != nil && len(mountFds) != len(config.Mounts) {
-		return fmt.Errorf("malformed mountFds slice. Expected size: %v, got: %v. Slice: %v", len(config.Mounts), len(mountFds), mountFds)
+	if mountFds.sourceFds != nil && len(mountFds.sourceFds) != len(config.Mounts) {
+		return fmt.Errorf("malformed mountFds slice. Expected size: %v, got: %v. Slice: %v", len(config.Mounts), len(mountFds.sourceFds), mountFds.sourceFds)
+	}
+	if mountFds.idmapFds != nil && len(mountFds.idmapFds) != len(config.Mounts) {
+		return fmt.Errorf("malformed idmapFds slice. Expected size: %v, got: %v. Slice: %v", len(config.Mounts), len(mountFds.idmapFds), mountFds.idmapFds)
 	}
 
 	mountConfig := &mountConfig{
@@ -66,16 +71,22 @@ func prepareRootfs(pipe io.ReadWriter, iConfig *initConfig, mountFds []int) (err
 		cgroup2Path:     iConfig.Cgroup2Path,
 		rootlessCgroups: iConfig.RootlessCgroups,
 		cgroupns:        config.Namespaces.Contains(configs.NEWCGROUP),
+		procfs:          config.Procfs,
 	}
 	setupDev := needsSetupDev(config)
 	for i, m := range config.Mounts {
 		// Just before the loop we checked that if not empty, len(mountFds) == len(config.Mounts).
 		// Therefore, we can access mountFds[i] without any concerns.
-		if mountFds != nil && mountFds[i] != -1 {
-			mountConfig.fd = &mountFds[i]
+		if mountFds.sourceFds != nil && mountFds.sourceFds[i] != -1 {
+			mountConfig.fd = &mountFds.sourceFds[i]
 		} else {
 			mountConfig.fd = nil
 		}
+		if mountFds.idmapFds != nil && mountFds.idmapFds[i] != -1 {
+			mountConfig.idmapFd = &mountFds.idmapFds[i]
+		} else {
+			mountConfig.idmapFd = nil
+		}
 
 		if err := mountToRootfs(m, mountConfig); err != nil {
 			return fmt.Errorf("error mounting %q to rootfs at %q: %w", m.Source, m.Destination, err)
@@ -399,6 +410,9 @@ func mountToRootfs(m *configs.Mount, c *mountConfig) error {
 		if err := os.MkdirAll(dest, 0o755); err != nil {
 			return err
 		}
+		if m.Device == "proc" && c.procfs != nil && utils.CleanPath(m.Destination) == "/proc" {
+			return mountProcfs(m, rootfs, c.procfs)
+		}
 		// Selinux kernels do not support labeling of /proc or /sys
 		return mountPropagate(m, rootfs, "", nil)
 	case "mqueue":
@@ -434,6 +448,11 @@ func mountToRootfs(m *configs.Mount, c *mountConfig) error {
 		}
 		return nil
 	case "bind":
+		if c.idmapFd != nil {
+			// The id-mapped mount has been created by nsexec from the
+			// source, so it is only left to attach it.
+			mountFd = c.idmapFd
+		}
 		if err := prepareBindMount(m, rootfs, mountFd); err != nil {
 			return err
 		}
@@ -571,6 +590,46 @@ func checkProcMount(rootfs, dest, source string) error {
 	return fmt.Errorf("%q cannot be mounted because it is inside /proc", dest)
 }
 
+// mountProcfs mounts a private procfs instance with the given options.
+func mountProcfs(m *configs.Mount, rootfs string, p *configs.Procfs) error {
+	var data []string
+	if m.Data != "" {
+		data = append(data, m.Data)
+	}
+	if p.SubsetPid {
+		data = append(data, "subset=pid")
+	}
+	if p.HidePid != "" {
+		data = append(data, "hidepid="+p.HidePid)
+	}
+	pm := *m
+	pm.Data = strings.Join(data, ",")
+	err := mountPropagate(&pm, rootfs, "", nil)
+	if errors.Is(err, unix.EINVAL) {
+		return fmt.Errorf("%w (private procfs options require Linux 5.8)", err)
+	}
+	return err
+}
+
+// procHidden returns whether path (inside the container) is a part of /proc
+// which does not appear in the procfs instance of the container, that is,
+// anything but the process directories if it is mounted with subset=pid.
+func procHidden(config *configs.Config, path string) bool {
+	if config.Procfs == nil || !config.Procfs.SubsetPid {
+		return false
+	}
+	rel, err := filepath.Rel("/proc", utils.CleanPath(path))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	name, _, _ := strings.Cut(rel, "/")
+	if name == "self" || name == "thread-self" {
+		return false
+	}
+	_, err = strconv.Atoi(name)
+	return err != nil
+}
+
 func isProc(path string) (bool, error) {
 	var s unix.Statfs_t
 	if err := unix.Statfs(path, &s); err != nil {
@@ -1087,6 +1146,9 @@ func mountPropagate(m *configs.Mount, rootfs string, mountLabel string, mountFd
 	}
 
 	if err := utils.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+		if m.IsIDMapped() && mountFd != nil {
+			return moveMount(*mountFd, m.Destination, procfd)
+		}
 		return mount(source, m.Destination, procfd, m.Device, uintptr(flags), data)
 	}); err != nil {
 		return err
@@ -46,6 +46,29 @@ func TestCheckMountDestNsLastPid(t *testing.T) {
 	}
 }
 
+func TestProcHidden(t *testing.T) {
+	config := &configs.Config{Procfs: &configs.Procfs{SubsetPid: true}}
+	for path, hidden := range map[string]bool{
+		"/proc":              false,
+		"/proc/kcore":        true,
+		"/proc/sys/kernel/":  true,
+		"/proc/self/attr":    false,
+		"/proc/thread-self":  false,
+		"/proc/1/mounts":     false,
+		"/proc2/kcore":       false,
+		"/sys/firmware":      false,
+		"/proc/../proc/keys": true,
+	} {
+		if procHidden(config, path) != hidden {
+			t.Errorf("%s: expected hidden to be %v", path, hidden)
+		}
+	}
+	config.Procfs.SubsetPid = false
+	if procHidden(config, "/proc/kcore") {
+		t.Error("/proc/kcore should not be hidden without subset=pid")
+	}
+}
+
 func TestNeedsSetupDev(t *testing.T) {
 	config := &configs.Config{
 		Mounts: []*configs.Mount{
@@ -54,6 +54,29 @@ func (l *linuxSetnsInit) Init() error {
 			return err
 		}
 	}
+	if p := l.config.Config.Personality; p != nil {
+		if err := system.SetLinuxPersonality(p.Domain); err != nil {
+			return err
+		}
+	}
+	// The scheduling policy and I/O priority are set before the
+	// capabilities are dropped, as the real-time ones need CAP_SYS_NICE
+	// (or CAP_SYS_ADMIN).
+	if l.config.Scheduler != nil {
+		if err := setupScheduler(l.config.Scheduler); err != nil {
+			return err
+		}
+	}
+	if l.config.IOPriority != nil {
+		if err := setupIOPriority(l.config.IOPriority); err != nil {
+			return err
+		}
+	}
+	if p := l.config.Config.MemoryPolicy; p != nil {
+		if err := setupMemoryPolicy(p); err != nil {
+			return err
+		}
+	}
 	if l.config.NoNewPrivileges {
 		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
 			return err
@@ -63,6 +86,13 @@ func (l *linuxSetnsInit) Init() error {
 		return err
 	}
 	defer selinux.SetExecLabel("") //nolint: errcheck
+	// Set the final CPU affinity as late as possible, but before seccomp
+	// (which could block sched_setaffinity).
+	if a := l.config.CPUAffinity; a != nil && a.Final != nil {
+		if err := unix.SchedSetaffinity(0, a.Final); err != nil {
+			return fmt.Errorf("error setting final CPU affinity: %w", err)
+		}
+	}
 	// Without NoNewPrivileges seccomp is a privileged operation, so we need to
 	// do this before dropping capabilities; otherwise do it as late as possible
 	// just before execve so as few syscalls take place after it as possible.
@@ -82,6 +112,13 @@ func (l *linuxSetnsInit) Init() error {
 	if err := apparmor.ApplyProfile(l.config.AppArmorProfile); err != nil {
 		return err
 	}
+	// Apply the Landlock ruleset before seccomp (which could block the
+	// landlock syscalls).
+	if l.config.Landlock != nil {
+		if err := setupLandlock(l.config); err != nil {
+			return err
+		}
+	}
 	// Set seccomp as close to execve as possible, so as few syscalls take
 	// place afterward (reducing the amount of syscalls that users need to
 	// enable in their seccomp profiles).
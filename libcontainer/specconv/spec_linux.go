@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -28,7 +29,11 @@ import (
 var (
 	initMapsOnce            sync.Once
 	namespaceMapping        map[specs.LinuxNamespaceType]configs.NamespaceType
+	personalityMapping      map[specs.LinuxPersonalityDomain]int
+	memoryPolicyModes       map[specs.MemoryPolicyModeType]int
+	memoryPolicyFlags       map[specs.MemoryPolicyFlagType]int
 	mountPropagationMapping map[string]int
+	idmapOptions            map[string]bool
 	recAttrFlags            map[string]struct {
 		clear bool
 		flag  uint64
@@ -49,6 +54,28 @@ func initMaps() {
 			specs.IPCNamespace:     configs.NEWIPC,
 			specs.UTSNamespace:     configs.NEWUTS,
 			specs.CgroupNamespace:  configs.NEWCGROUP,
+			specs.TimeNamespace:    configs.NEWTIME,
+		}
+
+		personalityMapping = map[specs.LinuxPersonalityDomain]int{
+			specs.PerLinux:   configs.PerLinux,
+			specs.PerLinux32: configs.PerLinux32,
+		}
+
+		memoryPolicyModes = map[specs.MemoryPolicyModeType]int{
+			specs.MpolDefault:            configs.MpolDefault,
+			specs.MpolPreferred:          configs.MpolPreferred,
+			specs.MpolBind:               configs.MpolBind,
+			specs.MpolInterleave:         configs.MpolInterleave,
+			specs.MpolLocal:              configs.MpolLocal,
+			specs.MpolPreferredMany:      configs.MpolPreferredMany,
+			specs.MpolWeightedInterleave: configs.MpolWeightedInterleave,
+		}
+
+		memoryPolicyFlags = map[specs.MemoryPolicyFlagType]int{
+			specs.MpolFNumaBalancing: configs.MpolFNumaBalancing,
+			specs.MpolFRelativeNodes: configs.MpolFRelativeNodes,
+			specs.MpolFStaticNodes:   configs.MpolFStaticNodes,
 		}
 
 		mountPropagationMapping = map[string]int{
@@ -125,7 +152,14 @@ func initMaps() {
 			"rnostrictatime": {true, unix.MOUNT_ATTR_STRICTATIME},
 			"rnosymfollow":   {false, unix.MOUNT_ATTR_NOSYMFOLLOW}, // since kernel 5.14
 			"rsymfollow":     {true, unix.MOUNT_ATTR_NOSYMFOLLOW},  // since kernel 5.14
-			// No support for MOUNT_ATTR_IDMAP yet (needs UserNS FD)
+			// MOUNT_ATTR_IDMAP is set with the "idmap" and "ridmap"
+			// options, see idmapOptions.
+		}
+
+		// The id-mapped mount options, and whether the mapping is recursive.
+		idmapOptions = map[string]bool{
+			"idmap":  false,
+			"ridmap": true,
 		}
 
 		extensionFlags = map[string]struct {
@@ -149,6 +183,18 @@ func KnownNamespaces() []string {
 	return res
 }
 
+// KnownPersonalityDomains returns the list of the known personality domains.
+// Used by `runc features`.
+func KnownPersonalityDomains() []string {
+	initMaps()
+	var res []string
+	for k := range personalityMapping {
+		res = append(res, string(k))
+	}
+	sort.Strings(res)
+	return res
+}
+
 // KnownMountOptions returns the list of the known mount options.
 // Used by `runc features`.
 func KnownMountOptions() []string {
@@ -163,6 +209,9 @@ func KnownMountOptions() []string {
 	for k := range recAttrFlags {
 		res = append(res, k)
 	}
+	for k := range idmapOptions {
+		res = append(res, k)
+	}
 	for k := range extensionFlags {
 		res = append(res, k)
 	}
@@ -310,6 +359,7 @@ type CreateOpts struct {
 	UseSystemdCgroup bool
 	NoPivotRoot      bool
 	NoNewKeyring     bool
+	Init             bool
 	Spec             *specs.Spec
 	RootlessEUID     bool
 	RootlessCgroups  bool
@@ -354,8 +404,10 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 		NoPivotRoot:     opts.NoPivotRoot,
 		Readonlyfs:      spec.Root.Readonly,
 		Hostname:        spec.Hostname,
+		Domainname:      spec.Domainname,
 		Labels:          append(labels, "bundle="+cwd),
 		NoNewKeyring:    opts.NoNewKeyring,
+		Init:            opts.Init,
 		RootlessEUID:    opts.RootlessEUID,
 		RootlessCgroups: opts.RootlessCgroups,
 	}
@@ -365,6 +417,9 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 		if err != nil {
 			return nil, fmt.Errorf("invalid mount %+v: %w", m, err)
 		}
+		if cm.Device == "proc" && filepath.Clean(cm.Destination) == "/proc" {
+			config.Procfs, cm.Data = procfsOptions(cm.Data)
+		}
 		config.Mounts = append(config.Mounts, cm)
 	}
 
@@ -419,6 +474,7 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 		config.ReadonlyPaths = spec.Linux.ReadonlyPaths
 		config.MountLabel = spec.Linux.MountLabel
 		config.Sysctl = spec.Linux.Sysctl
+		config.TimeOffsets = spec.Linux.TimeOffsets
 		if spec.Linux.Seccomp != nil {
 			seccomp, err := SetupSeccomp(spec.Linux.Seccomp)
 			if err != nil {
@@ -433,6 +489,23 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 				MemBwSchema:   spec.Linux.IntelRdt.MemBwSchema,
 			}
 		}
+		if p := spec.Linux.Personality; p != nil {
+			domain, ok := personalityMapping[p.Domain]
+			if !ok {
+				return nil, fmt.Errorf("personality domain %q is not supported", p.Domain)
+			}
+			// No flags are defined by the runtime spec.
+			if len(p.Flags) > 0 {
+				return nil, fmt.Errorf("personality flags %q are not supported", p.Flags)
+			}
+			config.Personality = &configs.LinuxPersonality{Domain: domain}
+		}
+		if p := spec.Linux.MemoryPolicy; p != nil {
+			config.MemoryPolicy, err = createMemoryPolicy(p)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// Set the host UID that should own the container's cgroup.
@@ -491,12 +564,72 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 				Ambient:     spec.Process.Capabilities.Ambient,
 			}
 		}
+		config.Scheduler = spec.Process.Scheduler
+		config.IOPriority = spec.Process.IOPriority
+		config.CPUAffinity, err = configs.ConvertCPUAffinity(spec.Process.ExecCPUAffinity)
+		if err != nil {
+			return nil, err
+		}
 	}
 	createHooks(spec, config)
 	config.Version = specs.Version
 	return config, nil
 }
 
+func createMemoryPolicy(p *specs.LinuxMemoryPolicy) (*configs.LinuxMemoryPolicy, error) {
+	mode, ok := memoryPolicyModes[p.Mode]
+	if !ok {
+		return nil, fmt.Errorf("memory policy mode %q is not supported", p.Mode)
+	}
+	mp := &configs.LinuxMemoryPolicy{Mode: mode}
+	for _, f := range p.Flags {
+		flag, ok := memoryPolicyFlags[f]
+		if !ok {
+			return nil, fmt.Errorf("memory policy flag %q is not supported", f)
+		}
+		mp.Flags |= flag
+	}
+	if p.Nodes != "" {
+		nodes, err := configs.ParseNodeSet(p.Nodes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory policy nodes %q: %w", p.Nodes, err)
+		}
+		mp.Nodes = nodes
+	}
+	return mp, nil
+}
+
+// procfsOptions moves the subset and hidepid options out of the mount data
+// of the container's /proc, into configs.Procfs.
+func procfsOptions(data string) (*configs.Procfs, string) {
+	var (
+		p    configs.Procfs
+		rest []string
+	)
+	if data == "" {
+		return nil, data
+	}
+	for _, o := range strings.Split(data, ",") {
+		key, val, _ := strings.Cut(o, "=")
+		switch {
+		case key == "subset" && val == "pid":
+			p.SubsetPid = true
+		case key == "hidepid":
+			// The numeric values are the older form of the option.
+			if name, ok := map[string]string{"0": "off", "1": "noaccess", "2": "invisible", "4": "ptraceable"}[val]; ok {
+				val = name
+			}
+			p.HidePid = val
+		default:
+			rest = append(rest, o)
+		}
+	}
+	if p == (configs.Procfs{}) {
+		return nil, data
+	}
+	return &p, strings.Join(rest, ",")
+}
+
 func createLibcontainerMount(cwd string, m specs.Mount) (*configs.Mount, error) {
 	if !filepath.IsAbs(m.Destination) {
 		// Relax validation for backward compatibility
@@ -519,6 +652,21 @@ func createLibcontainerMount(cwd string, m specs.Mount) (*configs.Mount, error)
 		}
 	}
 
+	// The mappings make it an id-mapped mount, even without the "idmap"
+	// option. Without mappings, an id-mapped mount is mapped to the user
+	// namespace of the container.
+	if len(m.UIDMappings) > 0 || len(m.GIDMappings) > 0 {
+		if mnt.IDMapping == nil {
+			mnt.IDMapping = &configs.MountIDMapping{}
+		}
+		for _, id := range m.UIDMappings {
+			mnt.IDMapping.UIDMappings = append(mnt.IDMapping.UIDMappings, toConfigIDMap(id))
+		}
+		for _, id := range m.GIDMappings {
+			mnt.IDMapping.GIDMappings = append(mnt.IDMapping.GIDMappings, toConfigIDMap(id))
+		}
+	}
+
 	// None of the mount arguments can contain a null byte. Normally such
 	// strings would either cause some other failure or would just be truncated
 	// when we hit the null byte, but because we serialise these strings as
@@ -749,8 +897,8 @@ func CreateCgroupConfig(opts *CreateOpts, defaultDevs []*devices.Device) (*confi
 				c.Resources.CpusetCpus = r.CPU.Cpus
 				c.Resources.CpusetMems = r.CPU.Mems
 			}
-			if r.Pids != nil {
-				c.Resources.PidsLimit = r.Pids.Limit
+			if r.Pids != nil && r.Pids.Limit != nil {
+				c.Resources.PidsLimit = *r.Pids.Limit
 			}
 			if r.BlockIO != nil {
 				if r.BlockIO.Weight != nil {
@@ -833,6 +981,9 @@ func CreateCgroupConfig(opts *CreateOpts, defaultDevs []*devices.Device) (*confi
 				for k, v := range r.Unified {
 					c.Resources.Unified[k] = v
 				}
+				if err := ConvertUnified(c.Resources); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
@@ -844,6 +995,93 @@ func CreateCgroupConfig(opts *CreateOpts, defaultDevs []*devices.Device) (*confi
 	return c, nil
 }
 
+// ConvertUnified moves the unified resources for which the runtime spec has
+// no fields to the typed resources: memory.high, memory.low and memory.min to
+// MemoryHigh, MemoryLow and MemoryMin, and io.latency to IOLatencyDevice
+// (replacing the target of the same device). This way, they are validated,
+// and also applied to cgroup v1 and by the systemd cgroup driver (where
+// possible).
+func ConvertUnified(r *configs.Resources) error {
+	for _, pair := range []struct {
+		key  string
+		dest **int64
+	}{
+		{"memory.high", &r.MemoryHigh},
+		{"memory.low", &r.MemoryLow},
+		{"memory.min", &r.MemoryMin},
+	} {
+		v, ok := r.Unified[pair.key]
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		n := int64(-1)
+		if v != "max" {
+			var err error
+			n, err = strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid unified resource %s value %q", pair.key, v)
+			}
+		}
+		*pair.dest = &n
+		delete(r.Unified, pair.key)
+	}
+	if v, ok := r.Unified["io.latency"]; ok {
+		for _, line := range strings.Split(v, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			ld, err := parseIOLatency(line)
+			if err != nil {
+				return fmt.Errorf("invalid unified resource io.latency value %q: %w", line, err)
+			}
+			r.IOLatencyDevice = setLatencyDevice(r.IOLatencyDevice, ld)
+		}
+		delete(r.Unified, "io.latency")
+	}
+	if len(r.Unified) == 0 {
+		r.Unified = nil
+	}
+	return nil
+}
+
+// parseIOLatency parses an io.latency line ("MAJ:MIN target=TARGET").
+func parseIOLatency(line string) (*configs.LatencyDevice, error) {
+	var major, minor int64
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return nil, errors.New("expected \"MAJ:MIN target=TARGET\"")
+	}
+	if n, err := fmt.Sscanf(fields[0], "%d:%d", &major, &minor); err != nil || n != 2 {
+		return nil, fmt.Errorf("invalid device %q", fields[0])
+	}
+	key, v, _ := strings.Cut(fields[1], "=")
+	if key != "target" {
+		return nil, fmt.Errorf("unknown key %q", key)
+	}
+	var target uint64
+	if v != "max" {
+		var err error
+		target, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return configs.NewLatencyDevice(major, minor, target), nil
+}
+
+// setLatencyDevice replaces the target of the device of ld in lds, or
+// appends ld if it is not there.
+func setLatencyDevice(lds []*configs.LatencyDevice, ld *configs.LatencyDevice) []*configs.LatencyDevice {
+	for _, d := range lds {
+		if d.BlockIODevice == ld.BlockIODevice {
+			d.Target = ld.Target
+			return lds
+		}
+	}
+	return append(lds, ld)
+}
+
 func stringToCgroupDeviceRune(s string) (devices.Type, error) {
 	switch s {
 	case "a":
@@ -927,20 +1165,21 @@ next:
 	return dedupedAllowDevs, nil
 }
 
-func setupUserNamespace(spec *specs.Spec, config *configs.Config) error {
-	create := func(m specs.LinuxIDMapping) configs.IDMap {
-		return configs.IDMap{
-			HostID:      int(m.HostID),
-			ContainerID: int(m.ContainerID),
-			Size:        int(m.Size),
-		}
+func toConfigIDMap(m specs.LinuxIDMapping) configs.IDMap {
+	return configs.IDMap{
+		HostID:      int(m.HostID),
+		ContainerID: int(m.ContainerID),
+		Size:        int(m.Size),
 	}
+}
+
+func setupUserNamespace(spec *specs.Spec, config *configs.Config) error {
 	if spec.Linux != nil {
 		for _, m := range spec.Linux.UIDMappings {
-			config.UidMappings = append(config.UidMappings, create(m))
+			config.UidMappings = append(config.UidMappings, toConfigIDMap(m))
 		}
 		for _, m := range spec.Linux.GIDMappings {
-			config.GidMappings = append(config.GidMappings, create(m))
+			config.GidMappings = append(config.GidMappings, toConfigIDMap(m))
 		}
 	}
 	rootUID, err := config.HostRootUID()
@@ -990,6 +1229,8 @@ func parseMountOptions(options []string) *configs.Mount {
 					recAttrClr |= unix.MOUNT_ATTR__ATIME
 				}
 			}
+		} else if recursive, exists := idmapOptions[o]; exists {
+			m.IDMapping = &configs.MountIDMapping{Recursive: recursive}
 		} else if f, exists := extensionFlags[o]; exists && f.flag != 0 {
 			if f.clear {
 				m.Extensions &= ^f.flag
@@ -2,6 +2,7 @@ package specconv
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -405,6 +406,67 @@ func TestLinuxCgroupWithMemoryResource(t *testing.T) {
 	}
 }
 
+func TestConvertUnified(t *testing.T) {
+	r := &configs.Resources{
+		Unified: map[string]string{
+			"memory.high": "max",
+			"memory.low":  "1024",
+			"memory.min":  "512",
+		},
+	}
+	if err := ConvertUnified(r); err != nil {
+		t.Fatal(err)
+	}
+	if r.MemoryHigh == nil || r.MemoryLow == nil || r.MemoryMin == nil {
+		t.Fatalf("expected high, low and min to be set, got %v, %v and %v", r.MemoryHigh, r.MemoryLow, r.MemoryMin)
+	}
+	if *r.MemoryHigh != -1 || *r.MemoryLow != 1024 || *r.MemoryMin != 512 {
+		t.Errorf("expected high -1, low 1024 and min 512, got %d, %d and %d", *r.MemoryHigh, *r.MemoryLow, *r.MemoryMin)
+	}
+	if r.Unified != nil {
+		t.Errorf("expected the memory keys to be removed from unified, got %v", r.Unified)
+	}
+
+	r = &configs.Resources{
+		Unified: map[string]string{
+			"memory.low":       "1024",
+			"memory.oom.group": "1",
+		},
+	}
+	if err := ConvertUnified(r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Unified) != 1 || r.Unified["memory.oom.group"] != "1" {
+		t.Errorf("expected the other unified keys to be kept, got %v", r.Unified)
+	}
+
+	for _, v := range []string{"", "1k", "-1"} {
+		r = &configs.Resources{Unified: map[string]string{"memory.high": v}}
+		if err := ConvertUnified(r); err == nil {
+			t.Errorf("%q: expected error, got nil", v)
+		}
+	}
+
+	r = &configs.Resources{
+		IOLatencyDevice: []*configs.LatencyDevice{configs.NewLatencyDevice(8, 0, 100)},
+		Unified:         map[string]string{"io.latency": "8:0 target=max\n8:16 target=25000\n"},
+	}
+	if err := ConvertUnified(r); err != nil {
+		t.Fatal(err)
+	}
+	expected := []*configs.LatencyDevice{configs.NewLatencyDevice(8, 0, 0), configs.NewLatencyDevice(8, 16, 25000)}
+	if !reflect.DeepEqual(r.IOLatencyDevice, expected) {
+		t.Errorf("expected io latency devices %+v, got %+v", expected, r.IOLatencyDevice)
+	}
+
+	for _, v := range []string{"8:0", "8 target=10", "8:0 latency=10", "8:0 target=10ms"} {
+		r = &configs.Resources{Unified: map[string]string{"io.latency": v}}
+		if err := ConvertUnified(r); err == nil {
+			t.Errorf("%q: expected error, got nil", v)
+		}
+	}
+}
+
 func TestLinuxCgroupSystemd(t *testing.T) {
 	cgroupsPath := "parent:scopeprefix:name"
 
@@ -608,6 +670,218 @@ func TestDupNamespaces(t *testing.T) {
 	}
 }
 
+func TestPersonality(t *testing.T) {
+	for _, tc := range []struct {
+		personality *specs.LinuxPersonality
+		domain      int
+		isErr       bool
+	}{
+		{personality: &specs.LinuxPersonality{Domain: specs.PerLinux}, domain: configs.PerLinux},
+		{personality: &specs.LinuxPersonality{Domain: specs.PerLinux32}, domain: configs.PerLinux32},
+		{personality: &specs.LinuxPersonality{Domain: "LINUX64"}, isErr: true},
+		{personality: &specs.LinuxPersonality{Domain: specs.PerLinux32, Flags: []specs.LinuxPersonalityFlag{"ADDR_NO_RANDOMIZE"}}, isErr: true},
+	} {
+		spec := &specs.Spec{
+			Root: &specs.Root{
+				Path: "rootfs",
+			},
+			Linux: &specs.Linux{
+				Personality: tc.personality,
+			},
+		}
+		config, err := CreateLibcontainerConfig(&CreateOpts{
+			Spec: spec,
+		})
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("%+v: expected error, got nil", tc.personality)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: %v", tc.personality, err)
+			continue
+		}
+		if config.Personality == nil || config.Personality.Domain != tc.domain {
+			t.Errorf("%+v: expected domain %#x, got %+v", tc.personality, tc.domain, config.Personality)
+		}
+	}
+}
+
+func TestMemoryPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy   *specs.LinuxMemoryPolicy
+		expected configs.LinuxMemoryPolicy
+		nodes    []int
+		isErr    bool
+	}{
+		{
+			policy:   &specs.LinuxMemoryPolicy{Mode: specs.MpolLocal},
+			expected: configs.LinuxMemoryPolicy{Mode: configs.MpolLocal},
+		},
+		{
+			policy:   &specs.LinuxMemoryPolicy{Mode: specs.MpolBind, Nodes: "0,2-3", Flags: []specs.MemoryPolicyFlagType{specs.MpolFStaticNodes, specs.MpolFNumaBalancing}},
+			expected: configs.LinuxMemoryPolicy{Mode: configs.MpolBind, Flags: configs.MpolFStaticNodes | configs.MpolFNumaBalancing},
+			nodes:    []int{0, 2, 3},
+		},
+		{policy: &specs.LinuxMemoryPolicy{Mode: "MPOL_FOO"}, isErr: true},
+		{policy: &specs.LinuxMemoryPolicy{Mode: specs.MpolBind, Nodes: "0", Flags: []specs.MemoryPolicyFlagType{"MPOL_F_FOO"}}, isErr: true},
+		{policy: &specs.LinuxMemoryPolicy{Mode: specs.MpolBind, Nodes: "1-0"}, isErr: true},
+	} {
+		spec := &specs.Spec{
+			Root: &specs.Root{
+				Path: "rootfs",
+			},
+			Linux: &specs.Linux{
+				MemoryPolicy: tc.policy,
+			},
+		}
+		config, err := CreateLibcontainerConfig(&CreateOpts{
+			Spec: spec,
+		})
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("%+v: expected error, got nil", tc.policy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: %v", tc.policy, err)
+			continue
+		}
+		p := config.MemoryPolicy
+		if p == nil || p.Mode != tc.expected.Mode || p.Flags != tc.expected.Flags {
+			t.Errorf("%+v: expected %+v, got %+v", tc.policy, tc.expected, p)
+			continue
+		}
+		if tc.nodes == nil {
+			if p.Nodes != nil {
+				t.Errorf("%+v: expected no nodes, got %v", tc.policy, p.Nodes)
+			}
+			continue
+		}
+		var nodes configs.NodeSet
+		for _, n := range tc.nodes {
+			(*unix.CPUSet)(&nodes).Set(n)
+		}
+		if p.Nodes == nil || *p.Nodes != nodes {
+			t.Errorf("%+v: expected nodes %v, got %v", tc.policy, tc.nodes, p.Nodes)
+		}
+	}
+}
+
+func TestProcfsOptions(t *testing.T) {
+	for _, tc := range []struct {
+		options  []string
+		expected *configs.Procfs
+		data     string
+	}{
+		{options: nil, expected: nil},
+		{options: []string{"nosuid", "gid=5"}, expected: nil, data: "gid=5"},
+		{options: []string{"subset=pid", "hidepid=invisible"}, expected: &configs.Procfs{SubsetPid: true, HidePid: "invisible"}},
+		{options: []string{"hidepid=1", "gid=5"}, expected: &configs.Procfs{HidePid: "noaccess"}, data: "gid=5"},
+	} {
+		spec := &specs.Spec{
+			Root: &specs.Root{
+				Path: "rootfs",
+			},
+			Mounts: []specs.Mount{
+				{Destination: "/proc", Type: "proc", Source: "proc", Options: tc.options},
+			},
+		}
+		config, err := CreateLibcontainerConfig(&CreateOpts{
+			Spec: spec,
+		})
+		if err != nil {
+			t.Errorf("%v: %v", tc.options, err)
+			continue
+		}
+		if (config.Procfs == nil) != (tc.expected == nil) || (tc.expected != nil && *config.Procfs != *tc.expected) {
+			t.Errorf("%v: expected %+v, got %+v", tc.options, tc.expected, config.Procfs)
+		}
+		if data := config.Mounts[0].Data; data != tc.data {
+			t.Errorf("%v: expected mount data %q, got %q", tc.options, tc.data, data)
+		}
+	}
+}
+
+func TestTimeNamespace(t *testing.T) {
+	offsets := map[string]specs.LinuxTimeOffset{
+		"boottime": {Secs: 864000},
+	}
+	spec := &specs.Spec{
+		Root: &specs.Root{
+			Path: "rootfs",
+		},
+		Linux: &specs.Linux{
+			Namespaces: []specs.LinuxNamespace{
+				{
+					Type: specs.TimeNamespace,
+				},
+			},
+			TimeOffsets: offsets,
+		},
+	}
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{
+		Spec: spec,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Namespaces.Contains(configs.NEWTIME) {
+		t.Errorf("expected a time namespace, got %+v", config.Namespaces)
+	}
+	if !reflect.DeepEqual(config.TimeOffsets, offsets) {
+		t.Errorf("expected time offsets %+v, got %+v", offsets, config.TimeOffsets)
+	}
+}
+
+func TestIDMappedMounts(t *testing.T) {
+	mapping := []specs.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	for _, tc := range []struct {
+		mount    specs.Mount
+		expected *configs.MountIDMapping
+	}{
+		{
+			mount: specs.Mount{Source: "/src", Destination: "/dst", Options: []string{"bind"}},
+		},
+		{
+			mount:    specs.Mount{Source: "/src", Destination: "/dst", Options: []string{"bind", "idmap"}},
+			expected: &configs.MountIDMapping{},
+		},
+		{
+			mount:    specs.Mount{Source: "/src", Destination: "/dst", Options: []string{"rbind", "ridmap"}},
+			expected: &configs.MountIDMapping{Recursive: true},
+		},
+		{
+			mount: specs.Mount{
+				Source:      "/src",
+				Destination: "/dst",
+				Options:     []string{"bind"},
+				UIDMappings: mapping,
+				GIDMappings: mapping,
+			},
+			expected: &configs.MountIDMapping{
+				UIDMappings: []configs.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}},
+				GIDMappings: []configs.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}},
+			},
+		},
+	} {
+		m, err := createLibcontainerMount("/", tc.mount)
+		if err != nil {
+			t.Errorf("%+v: %v", tc.mount, err)
+			continue
+		}
+		if !reflect.DeepEqual(m.IDMapping, tc.expected) {
+			t.Errorf("%+v: expected id-mapping %+v, got %+v", tc.mount, tc.expected, m.IDMapping)
+		}
+		if strings.Contains(m.Data, "idmap") {
+			t.Errorf("%+v: id-mapped mount options passed as data %q", tc.mount, m.Data)
+		}
+	}
+}
+
 func TestNonZeroEUIDCompatibleSpecconvValidate(t *testing.T) {
 	if _, err := os.Stat("/proc/self/ns/user"); os.IsNotExist(err) {
 		t.Skip("Test requires userns.")
@@ -15,6 +15,7 @@ import (
 	"github.com/opencontainers/runc/libcontainer/apparmor"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/keys"
+	"github.com/opencontainers/runc/libcontainer/landlock"
 	"github.com/opencontainers/runc/libcontainer/seccomp"
 	"github.com/opencontainers/runc/libcontainer/system"
 )
@@ -25,7 +26,7 @@ type linuxStandardInit struct {
 	parentPid     int
 	fifoFd        int
 	logFd         int
-	mountFds      []int
+	mountFds      mountFds
 	config        *initConfig
 }
 
@@ -88,7 +89,7 @@ func (l *linuxStandardInit) Init() error {
 
 	// We don't need the mountFds after prepareRootfs() nor if it fails.
 	err := prepareRootfs(l.pipe, l.config, l.mountFds)
-	for _, m := range l.mountFds {
+	for _, m := range append(l.mountFds.sourceFds, l.mountFds.idmapFds...) {
 		if m == -1 {
 			continue
 		}
@@ -126,8 +127,17 @@ func (l *linuxStandardInit) Init() error {
 			return &os.SyscallError{Syscall: "sethostname", Err: err}
 		}
 	}
-	if err := apparmor.ApplyProfile(l.config.AppArmorProfile); err != nil {
-		return fmt.Errorf("unable to apply apparmor profile: %w", err)
+	if domainname := l.config.Config.Domainname; domainname != "" {
+		if err := unix.Setdomainname([]byte(domainname)); err != nil {
+			return &os.SyscallError{Syscall: "setdomainname", Err: err}
+		}
+	}
+	// With Init, the profile is applied to runc init itself instead (see
+	// confineReaper), and inherited by the container process.
+	if !l.config.Config.Init {
+		if err := apparmor.ApplyProfile(l.config.AppArmorProfile); err != nil {
+			return fmt.Errorf("unable to apply apparmor profile: %w", err)
+		}
 	}
 
 	for key, value := range l.config.Config.Sysctl {
@@ -135,16 +145,47 @@ func (l *linuxStandardInit) Init() error {
 			return err
 		}
 	}
+	// The paths hidden by a private procfs instance do not need to be
+	// masked or made read-only.
 	for _, path := range l.config.Config.ReadonlyPaths {
+		if procHidden(l.config.Config, path) {
+			continue
+		}
 		if err := readonlyPath(path); err != nil {
 			return fmt.Errorf("can't make %q read-only: %w", path, err)
 		}
 	}
 	for _, path := range l.config.Config.MaskPaths {
+		if procHidden(l.config.Config, path) {
+			continue
+		}
 		if err := maskPath(path, l.config.Config.MountLabel); err != nil {
 			return fmt.Errorf("can't mask path %s: %w", path, err)
 		}
 	}
+	if p := l.config.Config.Personality; p != nil {
+		if err := system.SetLinuxPersonality(p.Domain); err != nil {
+			return err
+		}
+	}
+	// The scheduling policy and I/O priority are set before the
+	// capabilities are dropped, as the real-time ones need CAP_SYS_NICE
+	// (or CAP_SYS_ADMIN).
+	if l.config.Scheduler != nil {
+		if err := setupScheduler(l.config.Scheduler); err != nil {
+			return err
+		}
+	}
+	if l.config.IOPriority != nil {
+		if err := setupIOPriority(l.config.IOPriority); err != nil {
+			return err
+		}
+	}
+	if p := l.config.Config.MemoryPolicy; p != nil {
+		if err := setupMemoryPolicy(p); err != nil {
+			return err
+		}
+	}
 	pdeath, err := system.GetParentDeathSignal()
 	if err != nil {
 		return fmt.Errorf("can't get pdeath signal: %w", err)
@@ -164,6 +205,21 @@ func (l *linuxStandardInit) Init() error {
 		return fmt.Errorf("can't set process label: %w", err)
 	}
 	defer selinux.SetExecLabel("") //nolint: errcheck
+	// Set the final CPU affinity as late as possible, but before seccomp
+	// (which could block sched_setaffinity).
+	if a := l.config.CPUAffinity; a != nil && a.Final != nil {
+		if err := unix.SchedSetaffinity(0, a.Final); err != nil {
+			return fmt.Errorf("error setting final CPU affinity: %w", err)
+		}
+	}
+	// With Init, runc init stays as the container's init, so confine it
+	// like the container process, before seccomp, the capabilities and
+	// Landlock could prevent it.
+	if l.config.Config.Init {
+		if err := confineReaper(l.config); err != nil {
+			return err
+		}
+	}
 	// Without NoNewPrivileges seccomp is a privileged operation, so we need to
 	// do this before dropping capabilities; otherwise do it as late as possible
 	// just before execve so as few syscalls take place after it as possible.
@@ -204,6 +260,14 @@ func (l *linuxStandardInit) Init() error {
 	if err := system.Eaccess(name); err != nil {
 		return &os.PathError{Op: "exec", Path: name, Err: err}
 	}
+	// Apply the Landlock ruleset once everything else is set up, but before
+	// seccomp (which could block the landlock syscalls). The exec fifo is
+	// still to be opened for writing, so it is allowed explicitly.
+	if l.config.Landlock != nil {
+		if err := setupLandlock(l.config, landlock.FDRule{Fd: l.fifoFd, Access: []string{"write_file"}}); err != nil {
+			return err
+		}
+	}
 
 	// Set seccomp as close to execve as possible, so as few syscalls take
 	// place afterward (reducing the amount of syscalls that users need to
@@ -257,5 +321,8 @@ func (l *linuxStandardInit) Init() error {
 		return err
 	}
 
+	if l.config.Config.Init {
+		return runReaper(name, l.config, fd)
+	}
 	return system.Exec(name, l.config.Args[0:], os.Environ())
 }
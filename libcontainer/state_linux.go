@@ -48,6 +48,14 @@ func destroy(c *Container) error {
 			err = ierr
 		}
 	}
+	// Record the stopped event, unless the exit of init has been seen
+	// already (see recordStopped).
+	var pid int
+	if c.initProcess != nil {
+		pid = c.initProcess.pid()
+	}
+	c.recordEvent(EventStopped, pid, nil)
+	c.recordEvent(EventDeleted, 0, nil)
 	if rerr := os.RemoveAll(c.root); err == nil {
 		err = rerr
 	}
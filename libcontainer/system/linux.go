@@ -106,6 +106,63 @@ func Setctty() error {
 	return nil
 }
 
+// SetLinuxPersonality sets the personality (execution domain) of the calling
+// process, see personality(2).
+func SetLinuxPersonality(personality int) error {
+	_, _, errno := unix.Syscall(unix.SYS_PERSONALITY, uintptr(personality), 0, 0)
+	if errno != 0 {
+		return &os.SyscallError{Syscall: "personality", Err: errno}
+	}
+	return nil
+}
+
+// SchedAttr is the scheduling policy and attributes of a thread, as used by
+// sched_setattr(2) (struct sched_attr).
+type SchedAttr struct {
+	Size     uint32
+	Policy   uint32
+	Flags    uint64
+	Nice     int32
+	Priority uint32
+	Runtime  uint64
+	Deadline uint64
+	Period   uint64
+	UtilMin  uint32
+	UtilMax  uint32
+}
+
+// SchedSetattr sets the scheduling policy and attributes of the thread pid
+// (or the calling thread if pid is 0), see sched_setattr(2).
+func SchedSetattr(pid int, attr *SchedAttr, flags uint) error {
+	attr.Size = uint32(unsafe.Sizeof(*attr))
+	_, _, errno := unix.Syscall(unix.SYS_SCHED_SETATTR, uintptr(pid), uintptr(unsafe.Pointer(attr)), uintptr(flags))
+	if errno != 0 {
+		return &os.SyscallError{Syscall: "sched_setattr", Err: errno}
+	}
+	return nil
+}
+
+// IOPrioSet sets the I/O priority of the calling thread, see ioprio_set(2).
+func IOPrioSet(ioprio int) error {
+	const ioprioWhoProcess = 1
+	_, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(ioprio))
+	if errno != 0 {
+		return &os.SyscallError{Syscall: "ioprio_set", Err: errno}
+	}
+	return nil
+}
+
+// SetMempolicy sets the NUMA memory policy of the calling thread, see
+// set_mempolicy(2). The mode includes the mode flags, and nodemask points to
+// a node mask of maxnode bits (or is nil).
+func SetMempolicy(mode int, nodemask unsafe.Pointer, maxnode uint) error {
+	_, _, errno := unix.Syscall(unix.SYS_SET_MEMPOLICY, uintptr(mode), uintptr(nodemask), uintptr(maxnode))
+	if errno != 0 {
+		return &os.SyscallError{Syscall: "set_mempolicy", Err: errno}
+	}
+	return nil
+}
+
 // SetSubreaper sets the value i as the subreaper setting for the calling process
 func SetSubreaper(i int) error {
 	return unix.Prctl(unix.PR_SET_CHILD_SUBREAPER, uintptr(i), 0, 0, 0)
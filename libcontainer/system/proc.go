@@ -60,9 +60,24 @@ type Stat_t struct {
 	// State is the state of the process.
 	State State
 
+	// PPid is the pid of the parent process.
+	PPid int
+
+	// UTime and STime are the amounts of time the process has been
+	// scheduled in user and kernel mode, in clock ticks.
+	UTime, STime uint64
+
 	// StartTime is the number of clock ticks after system boot (since
 	// Linux 2.6).
 	StartTime uint64
+
+	// RSS is the resident set size of the process, in pages.
+	RSS uint64
+
+	// ExitCode is the exit status of a zombie process, in the form reported
+	// by waitpid(2) (since Linux 3.5, and only if the process may be
+	// ptraced).
+	ExitCode int
 }
 
 // Stat returns a Stat_t instance for the specified process.
@@ -83,7 +98,11 @@ func parseStat(data string) (stat Stat_t, err error) {
 	//  * field 2: process name. It is the only field enclosed into
 	//    parenthesis, as it can contain spaces (and parenthesis) inside.
 	//  * field 3: process state, a single character (%c)
+	//  * field 4: parent pid, an integer (%d)
+	//  * fields 14 and 15: user and system time, unsigned longs (%lu)
 	//  * field 22: process start time, a long unsigned integer (%llu).
+	//  * field 24: resident set size, a long integer (%ld).
+	//  * field 52: exit status, an integer (%d).
 
 	// 1. Look for the first '(' and the last ')' first, what's in between is Name.
 	//    We expect at least 20 fields and a space after the last one.
@@ -123,5 +142,32 @@ func parseStat(data string) (stat Stat_t, err error) {
 		return stat, fmt.Errorf("invalid stat data (bad start time): %w", err)
 	}
 
+	// 4. The other fields are numbers, so they can simply be split now
+	//    (fields[0] being field 3). RSS and the exit status are optional,
+	//    as they come after the minimal input.
+	fields := strings.Fields(data)
+	if len(fields) < 22-3+1 {
+		return stat, fmt.Errorf("invalid stat data (too short): %q", data)
+	}
+	if stat.PPid, err = strconv.Atoi(fields[4-3]); err != nil {
+		return stat, fmt.Errorf("invalid stat data (bad ppid): %w", err)
+	}
+	if stat.UTime, err = strconv.ParseUint(fields[14-3], 10, 64); err != nil {
+		return stat, fmt.Errorf("invalid stat data (bad utime): %w", err)
+	}
+	if stat.STime, err = strconv.ParseUint(fields[15-3], 10, 64); err != nil {
+		return stat, fmt.Errorf("invalid stat data (bad stime): %w", err)
+	}
+	if len(fields) > 24-3 {
+		if stat.RSS, err = strconv.ParseUint(fields[24-3], 10, 64); err != nil {
+			return stat, fmt.Errorf("invalid stat data (bad rss): %w", err)
+		}
+	}
+	if len(fields) > 52-3 {
+		if stat.ExitCode, err = strconv.Atoi(fields[52-3]); err != nil {
+			return stat, fmt.Errorf("invalid stat data (bad exit code): %w", err)
+		}
+	}
+
 	return stat, nil
 }
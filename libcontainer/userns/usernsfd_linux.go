@@ -0,0 +1,56 @@
+package userns
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func toSysIDMap(idMap []configs.IDMap) []syscall.SysProcIDMap {
+	res := make([]syscall.SysProcIDMap, 0, len(idMap))
+	for _, m := range idMap {
+		res = append(res, syscall.SysProcIDMap{
+			ContainerID: m.ContainerID,
+			HostID:      m.HostID,
+			Size:        m.Size,
+		})
+	}
+	return res
+}
+
+// NewUserNamespace creates a new user namespace with the given mappings, and
+// returns a file referring to it, which keeps it alive until it is closed.
+// This is needed to create id-mapped mounts (see mount_setattr(2)), which
+// take a user namespace rather than the mappings themselves.
+func NewUserNamespace(uidMap, gidMap []configs.IDMap) (*os.File, error) {
+	// A user namespace can only be created along with a process (which is
+	// also the only way to write its mappings from the parent). Rather than
+	// executing a real program, the process is put in PTRACE_TRACEME mode,
+	// so that it stops right at execve(2) and never runs anything.
+	proc, err := os.StartProcess("/proc/self/exe", []string{"runc-userns"}, &os.ProcAttr{
+		Sys: &syscall.SysProcAttr{
+			Cloneflags:  unix.CLONE_NEWUSER,
+			UidMappings: toSysIDMap(uidMap),
+			GidMappings: toSysIDMap(gidMap),
+			Ptrace:      true,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to spawn a process for the user namespace: %w", err)
+	}
+	defer func() {
+		_ = proc.Kill()
+		_, _ = proc.Wait()
+	}()
+
+	path := fmt.Sprintf("/proc/%d/ns/user", proc.Pid)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -38,6 +38,9 @@ type containerState struct {
 	Annotations map[string]string `json:"annotations,omitempty"`
 	// The owner of the state directory (the owner of the container).
 	Owner string `json:"owner"`
+	// ExitStatus is the exit status of the container's init process, if it
+	// has stopped and the exit status was recorded.
+	ExitStatus *libcontainer.ExitStatus `json:"exitStatus,omitempty"`
 }
 
 var listCommand = cli.Command{
@@ -157,8 +160,10 @@ func getContainers(context *cli.Context) ([]containerState, error) {
 			continue
 		}
 		pid := state.BaseState.InitProcessPid
+		var exitStatus *libcontainer.ExitStatus
 		if containerStatus == libcontainer.Stopped {
 			pid = 0
+			exitStatus = state.BaseState.ExitStatus
 		}
 		bundle, annotations := utils.Annotations(state.Config.Labels)
 		s = append(s, containerState{
@@ -171,6 +176,7 @@ func getContainers(context *cli.Context) ([]containerState, error) {
 			Created:        state.BaseState.Created,
 			Annotations:    annotations,
 			Owner:          owner.Name,
+			ExitStatus:     exitStatus,
 		})
 	}
 	return s, nil
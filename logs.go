@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
+)
+
+var logsCommand = cli.Command{
+	Name:  "logs",
+	Usage: "print the output of a container",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is the name for the instance of the container.`,
+	Description: `The logs command prints the stdout and stderr of a container created with the
+--capture-stdio option to runc's stdout and stderr, respectively.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "follow, f",
+			Usage: "keep printing the output until the container exits",
+		},
+		cli.StringFlag{
+			Name:  "since",
+			Usage: "only print the output written since the given time (RFC 3339 timestamp, or duration relative to now, e.g. 10m)",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		var since time.Time
+		if val := context.String("since"); val != "" {
+			since, err = parseSince(val)
+			if err != nil {
+				return err
+			}
+		}
+		dir := filepath.Join(context.GlobalString("root"), container.ID())
+		r := &stdioLogReader{
+			path:  filepath.Join(dir, stdioLogFilename),
+			since: since,
+		}
+		if context.Bool("follow") {
+			return r.follow(filepath.Join(dir, monitorPidFilename))
+		}
+		return r.readAll()
+	},
+}
+
+// parseSince parses the value of the --since option.
+func parseSince(val string) (time.Time, error) {
+	if d, err := time.ParseDuration(val); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid value for since: %q is neither a timestamp nor a duration", val)
+	}
+	return t, nil
+}
+
+// stdioLogReader prints the entries of a stdio log (see stdioLog).
+type stdioLogReader struct {
+	path  string
+	since time.Time
+	// partial is the incomplete last line read from the log file.
+	partial []byte
+}
+
+// rotatedFiles returns the paths of the log files, oldest first.
+func (r *stdioLogReader) rotatedFiles() ([]string, error) {
+	if _, err := os.Stat(r.path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("container output is not captured (use --capture-stdio)")
+		}
+		return nil, err
+	}
+	var files []string
+	for i := 1; ; i++ {
+		p := r.path + "." + strconv.Itoa(i)
+		if _, err := os.Stat(p); err != nil {
+			break
+		}
+		files = append([]string{p}, files...)
+	}
+	return files, nil
+}
+
+// readAll prints all the entries of the log.
+func (r *stdioLogReader) readAll() error {
+	files, err := r.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for _, p := range append(files, r.path) {
+		f, err := os.Open(p)
+		if err != nil {
+			// The file might have been rotated away in the meantime.
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		err = r.copy(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		r.partial = nil
+	}
+	return nil
+}
+
+// follow prints the entries of the log, and then keeps printing the new
+// ones until the container monitor (whose pid is in monitorPidFile) exits.
+func (r *stdioLogReader) follow(monitorPidFile string) error {
+	files, err := r.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for _, p := range files {
+		if err := r.readFile(p); err != nil {
+			return err
+		}
+	}
+	f, err := os.Open(r.path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+	for {
+		// Check the monitor before reading, so that the final output is
+		// printed once it has exited.
+		running := isMonitorRunning(monitorPidFile)
+		if err := r.copy(f); err != nil {
+			return err
+		}
+		rotated, err := isRotated(f, r.path)
+		if err != nil {
+			return err
+		}
+		if rotated {
+			// Print the rest of the old file, and continue with the new one.
+			if err := r.copy(f); err != nil {
+				return err
+			}
+			f.Close()
+			r.partial = nil
+			if f, err = os.Open(r.path); err != nil {
+				return err
+			}
+			continue
+		}
+		if !running {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (r *stdioLogReader) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	r.partial = nil
+	return r.copy(f)
+}
+
+// isRotated reports whether the file at path is not f anymore.
+func isRotated(f *os.File, path string) (bool, error) {
+	var st1, st2 unix.Stat_t
+	if err := unix.Fstat(int(f.Fd()), &st1); err != nil {
+		return false, &os.PathError{Op: "fstat", Path: f.Name(), Err: err}
+	}
+	if err := unix.Stat(path, &st2); err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return false, nil
+		}
+		return false, &os.PathError{Op: "stat", Path: path, Err: err}
+	}
+	return st1.Dev != st2.Dev || st1.Ino != st2.Ino, nil
+}
+
+// copy prints the entries read from f until EOF. An incomplete last line
+// is kept until the next call.
+func (r *stdioLogReader) copy(f io.Reader) error {
+	rd := bufio.NewReader(f)
+	for {
+		line, err := rd.ReadBytes('\n')
+		if len(line) > 0 {
+			r.partial = append(r.partial, line...)
+			if r.partial[len(r.partial)-1] == '\n' {
+				if perr := r.print(r.partial); perr != nil {
+					return perr
+				}
+				r.partial = nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// print prints a single log entry.
+func (r *stdioLogReader) print(line []byte) error {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil
+	}
+	var entry stdioLogEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return fmt.Errorf("invalid log entry: %w", err)
+	}
+	if entry.Time.Before(r.since) {
+		return nil
+	}
+	out := os.Stdout
+	if entry.Stream == "stderr" {
+		out = os.Stderr
+	}
+	_, err := io.WriteString(out, entry.Log)
+	return err
+}
@@ -115,6 +115,7 @@ func main() {
 		},
 	}
 	app.Commands = []cli.Command{
+		attachCommand,
 		checkpointCommand,
 		createCommand,
 		deleteCommand,
@@ -122,6 +123,8 @@ func main() {
 		execCommand,
 		killCommand,
 		listCommand,
+		logsCommand,
+		metricsCommand,
 		pauseCommand,
 		psCommand,
 		restoreCommand,
@@ -130,7 +133,9 @@ func main() {
 		specCommand,
 		startCommand,
 		stateCommand,
+		stopCommand,
 		updateCommand,
+		waitCommand,
 		featuresCommand,
 	}
 	app.Before = func(context *cli.Context) error {
@@ -0,0 +1,132 @@
+package main
+
+import (
+	gocontext "context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+var metricsCommand = cli.Command{
+	Name:  "metrics",
+	Usage: "serve the stats of all containers in the OpenMetrics format",
+	Description: `The metrics command serves the statistics of all the containers under the runc
+root directory (see --root) over HTTP, on the /metrics path, in the OpenMetrics
+text format, so that they can be scraped by Prometheus or compatible agents.
+The metrics are labelled by container ID, and by the container annotations (as
+"annotation_<key>" labels, where the characters of the key which are not
+allowed in label names are replaced with underscores).
+
+The address to listen on is either a TCP address (host:port), or the path of a
+unix socket (either absolute, or prefixed with "unix:").
+
+EXAMPLE:
+To serve the metrics on port 9100 of the loopback interface:
+
+       # runc metrics --listen 127.0.0.1:9100`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "listen, l",
+			Usage: "TCP address or unix socket path to listen on",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 0, exactArgs); err != nil {
+			return err
+		}
+		addr := context.String("listen")
+		if addr == "" {
+			return errors.New("--listen is required")
+		}
+		network := "tcp"
+		if strings.HasPrefix(addr, "unix:") || filepath.IsAbs(addr) {
+			network, addr = "unix", strings.TrimPrefix(addr, "unix:")
+		}
+		l, err := net.Listen(network, addr)
+		if err != nil {
+			return err
+		}
+		if network == "unix" {
+			defer os.Remove(addr)
+		}
+
+		root := context.GlobalString("root")
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			m := newOpenMetricsWriter()
+			for _, c := range collectMetrics(root) {
+				m.addContainerMetrics(c)
+			}
+			w.Header().Set("Content-Type", openMetricsContentType)
+			if err := m.writeTo(w); err != nil {
+				logrus.Debugf("unable to write metrics: %v", err)
+			}
+		})
+		srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, unix.SIGINT, unix.SIGTERM)
+		go func() {
+			<-sigc
+			ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 5*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(ctx)
+		}()
+		if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+// collectMetrics returns the stats of all the containers under root which
+// are not stopped. The containers whose stats can not be read are skipped.
+func collectMetrics(root string) []*metricsContainer {
+	list, err := os.ReadDir(root)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logrus.Warnf("unable to list containers: %v", err)
+		}
+		return nil
+	}
+	var containers []*metricsContainer
+	for _, item := range list {
+		if !item.IsDir() {
+			continue
+		}
+		container, err := libcontainer.Load(root, item.Name())
+		if err != nil {
+			// Possible race with runc delete.
+			logrus.Debugf("load container %s: %v", item.Name(), err)
+			continue
+		}
+		status, err := container.Status()
+		if err != nil || status == libcontainer.Stopped {
+			continue
+		}
+		stats, err := container.Stats()
+		if err != nil {
+			logrus.Warnf("stats for %s: %v", container.ID(), err)
+			continue
+		}
+		_, annotations := utils.Annotations(container.Config().Labels)
+		containers = append(containers, &metricsContainer{
+			id:          container.ID(),
+			annotations: annotations,
+			stats:       convertLibcontainerStats(stats),
+		})
+	}
+	return containers
+}
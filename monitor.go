@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+const (
+	// monitorPipeEnv is set for the runc process which acts as a container
+	// monitor. Its value is the fd number of the pipe used to tell the
+	// original runc process that the container has been created.
+	monitorPipeEnv = "_RUNC_MONITOR_PIPE"
+
+	// monitorPidFilename is the name of the file in the container state
+	// directory which holds the pid of the container monitor while it is
+	// running.
+	monitorPidFilename = "monitor.pid"
+)
+
+// execMonitor re-executes runc with the same arguments in a new session,
+// where it creates the container and stays behind as the parent of the
+// container's init process, so it can record its exit status. It waits for
+// the container to be created and returns the exit status the original
+// runc invocation should exit with.
+func execMonitor(context *cli.Context) (int, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return -1, err
+	}
+	defer r.Close()
+
+	cmd := exec.Command("/proc/self/exe", os.Args[1:]...)
+	cmd.Args[0] = os.Args[0]
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &unix.SysProcAttr{Setsid: true}
+	// Pass the fds meant for the container ($LISTEN_FDS and --preserve-fds)
+	// at the same numbers, followed by the pipe.
+	n := context.Int("preserve-fds")
+	if os.Getenv("LISTEN_FDS") != "" {
+		listenFds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		if err != nil {
+			return -1, fmt.Errorf("invalid LISTEN_FDS: %w", err)
+		}
+		n += listenFds
+	}
+	for i := 3; i < 3+n; i++ {
+		cmd.ExtraFiles = append(cmd.ExtraFiles, os.NewFile(uintptr(i), "fd:"+strconv.Itoa(i)))
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, w)
+	cmd.Env = append(os.Environ(), monitorPipeEnv+"="+strconv.Itoa(2+len(cmd.ExtraFiles)))
+
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		return -1, fmt.Errorf("unable to start container monitor: %w", err)
+	}
+	// The monitor writes a byte once the container is created. Otherwise,
+	// it has failed (and reported the error itself), and its exit status
+	// is ours.
+	if n, _ := r.Read(make([]byte, 1)); n == 1 {
+		_ = cmd.Process.Release()
+		return 0, nil
+	}
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, fmt.Errorf("container monitor: %w", err)
+	}
+	return -1, errors.New("container monitor exited unexpectedly")
+}
+
+// containerMonitor is the state of a runc process acting as a container
+// monitor (see execMonitor).
+type containerMonitor struct {
+	// pipe is used to tell the original runc process that the container
+	// has been created.
+	pipe *os.File
+	// pidFile is the path to the file with the monitor pid.
+	pidFile string
+}
+
+// newContainerMonitor returns a containerMonitor if the current process has
+// been started by execMonitor, and nil otherwise.
+func newContainerMonitor(context *cli.Context) (*containerMonitor, error) {
+	env := os.Getenv(monitorPipeEnv)
+	if env == "" {
+		return nil, nil
+	}
+	os.Unsetenv(monitorPipeEnv)
+	fd, err := strconv.Atoi(env)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert %s: %w", monitorPipeEnv, err)
+	}
+	unix.CloseOnExec(fd)
+	// The socket activation fds were meant for the original runc process.
+	if os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getppid()) {
+		os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	}
+	return &containerMonitor{
+		pipe:    os.NewFile(uintptr(fd), "monitor-pipe"),
+		pidFile: filepath.Join(context.GlobalString("root"), context.Args().First(), monitorPidFilename),
+	}, nil
+}
+
+// monitor tells the original runc process that the container is created,
+// then waits for the container's init process to exit and records its exit
+// status. It returns the exit status of the init process.
+func (r *runner) monitor(handler *signalHandler, process *libcontainer.Process, t *tty) (int, error) {
+	pid, err := process.Pid()
+	if err != nil {
+		return -1, err
+	}
+	m := r.containerMonitor
+	if err := os.WriteFile(m.pidFile, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		return -1, err
+	}
+	defer os.Remove(m.pidFile)
+
+	if _, err := m.pipe.Write([]byte{0}); err != nil {
+		return -1, err
+	}
+	m.pipe.Close()
+	// Do not hold on to the stdio of whoever has started us.
+	if err := detachStdio(); err != nil {
+		logrus.Warnf("container monitor: %v", err)
+	}
+
+	ws, err := handler.waitFor(pid)
+	if err != nil {
+		return -1, err
+	}
+	r.recordExit(ws)
+	// Wait for the container output to be written to the stdio log and
+	// sent to the attach clients, if any, before going away.
+	t.Close()
+	if r.attachRelay != nil {
+		r.attachRelay.close(utils.ExitStatus(ws))
+	}
+	return utils.ExitStatus(ws), nil
+}
+
+// detachStdio replaces stdin, stdout and stderr with /dev/null.
+func detachStdio() error {
+	null, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer null.Close()
+	for fd := 0; fd < 3; fd++ {
+		if err := unix.Dup3(int(null.Fd()), fd, 0); err != nil {
+			return &os.SyscallError{Syscall: "dup3", Err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runc/types"
+)
+
+// openMetricsContentType is the content type of the OpenMetrics text format.
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// metricsContainer is a container whose stats are exported as metrics.
+type metricsContainer struct {
+	id          string
+	annotations map[string]string
+	stats       *types.Stats
+}
+
+// metricFamily is a metric family of the OpenMetrics text format, along with
+// its samples for all the containers.
+type metricFamily struct {
+	name, typ, help string
+	samples         []metricSample
+}
+
+type metricSample struct {
+	// labels is the formatted label set, without the braces.
+	labels string
+	value  string
+}
+
+// openMetricsWriter collects the metrics of the containers, so that the
+// samples of each metric family can be written together, as required by the
+// OpenMetrics text format.
+type openMetricsWriter struct {
+	families []*metricFamily
+	byName   map[string]*metricFamily
+}
+
+func newOpenMetricsWriter() *openMetricsWriter {
+	return &openMetricsWriter{byName: make(map[string]*metricFamily)}
+}
+
+func (w *openMetricsWriter) add(name, typ, help, labels, value string) {
+	f, ok := w.byName[name]
+	if !ok {
+		f = &metricFamily{name: name, typ: typ, help: help}
+		w.families = append(w.families, f)
+		w.byName[name] = f
+	}
+	f.samples = append(f.samples, metricSample{labels: labels, value: value})
+}
+
+func (w *openMetricsWriter) gauge(name, help, labels string, value uint64) {
+	w.add(name, "gauge", help, labels, strconv.FormatUint(value, 10))
+}
+
+func (w *openMetricsWriter) counter(name, help, labels string, value uint64) {
+	w.add(name, "counter", help, labels, strconv.FormatUint(value, 10))
+}
+
+// seconds adds a counter of seconds, from a value in nanoseconds.
+func (w *openMetricsWriter) seconds(name, help, labels string, ns uint64) {
+	w.add(name, "counter", help, labels, strconv.FormatFloat(float64(ns)/1e9, 'f', -1, 64))
+}
+
+// writeTo writes the collected metrics to out, in the OpenMetrics text format.
+func (w *openMetricsWriter) writeTo(out io.Writer) error {
+	bw := bufio.NewWriter(out)
+	for _, f := range w.families {
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.typ)
+		for _, unit := range []string{"bytes", "seconds"} {
+			if strings.HasSuffix(f.name, "_"+unit) {
+				fmt.Fprintf(bw, "# UNIT %s %s\n", f.name, unit)
+			}
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, f.help)
+		name := f.name
+		if f.typ == "counter" {
+			name += "_total"
+		}
+		for _, s := range f.samples {
+			fmt.Fprintf(bw, "%s{%s} %s\n", name, s.labels, s.value)
+		}
+	}
+	fmt.Fprint(bw, "# EOF\n")
+	return bw.Flush()
+}
+
+// metricLabels formats the labels of the metrics of container c, followed by
+// the extra label pairs given in extra.
+func metricLabels(c *metricsContainer, extra ...string) string {
+	var b strings.Builder
+	writeLabel := func(name, value string) {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(value))
+		b.WriteByte('"')
+	}
+	writeLabel("id", c.id)
+	keys := make([]string, 0, len(c.annotations))
+	for k := range c.annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	seen := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		name := annotationLabelName(k)
+		// Different annotations may end up with the same label name.
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		writeLabel(name, c.annotations[k])
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		writeLabel(extra[i], extra[i+1])
+	}
+	return b.String()
+}
+
+// annotationLabelName returns the label name for the annotation key, in which
+// the characters not allowed in label names are replaced with underscores.
+func annotationLabelName(key string) string {
+	return "annotation_" + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// addContainerMetrics collects the metrics for the stats of container c.
+func (w *openMetricsWriter) addContainerMetrics(c *metricsContainer) {
+	s := c.stats
+	if s == nil {
+		return
+	}
+	l := metricLabels(c)
+
+	w.seconds("runc_container_cpu_usage_seconds", "Total CPU time consumed.", l, s.CPU.Usage.Total)
+	w.seconds("runc_container_cpu_user_seconds", "CPU time consumed in user mode.", l, s.CPU.Usage.User)
+	w.seconds("runc_container_cpu_kernel_seconds", "CPU time consumed in kernel mode.", l, s.CPU.Usage.Kernel)
+	w.counter("runc_container_cpu_throttling_periods", "Number of CPU enforcement periods elapsed.", l, s.CPU.Throttling.Periods)
+	w.counter("runc_container_cpu_throttled_periods", "Number of CPU enforcement periods in which the container was throttled.", l, s.CPU.Throttling.ThrottledPeriods)
+	w.seconds("runc_container_cpu_throttled_seconds", "Total time the container was throttled for.", l, s.CPU.Throttling.ThrottledTime)
+
+	w.gauge("runc_container_memory_usage_bytes", "Current memory usage.", l, s.Memory.Usage.Usage)
+	w.gauge("runc_container_memory_max_usage_bytes", "Maximum memory usage recorded.", l, s.Memory.Usage.Max)
+	w.gauge("runc_container_memory_limit_bytes", "Memory limit.", l, s.Memory.Usage.Limit)
+	w.counter("runc_container_memory_failcnt", "Number of times the memory limit was hit.", l, s.Memory.Usage.Failcnt)
+	w.gauge("runc_container_memory_swap_usage_bytes", "Current swap usage.", l, s.Memory.Swap.Usage)
+	w.gauge("runc_container_memory_swap_limit_bytes", "Swap limit.", l, s.Memory.Swap.Limit)
+	w.gauge("runc_container_memory_kernel_usage_bytes", "Current kernel memory usage.", l, s.Memory.Kernel.Usage)
+	w.gauge("runc_container_memory_cache_bytes", "Current page cache usage.", l, s.Memory.Cache)
+
+	w.gauge("runc_container_pids_current", "Current number of processes.", l, s.Pids.Current)
+	w.gauge("runc_container_pids_limit", "Limit of the number of processes (0 if unlimited).", l, s.Pids.Limit)
+
+	for _, e := range s.Blkio.IoServiceBytesRecursive {
+		el := metricLabels(c, "major", strconv.FormatUint(e.Major, 10), "minor", strconv.FormatUint(e.Minor, 10), "op", e.Op)
+		w.counter("runc_container_blkio_io_service_bytes", "Number of bytes transferred to and from the block device.", el, e.Value)
+	}
+	for _, e := range s.Blkio.IoServicedRecursive {
+		el := metricLabels(c, "major", strconv.FormatUint(e.Major, 10), "minor", strconv.FormatUint(e.Minor, 10), "op", e.Op)
+		w.counter("runc_container_blkio_io_serviced", "Number of I/O operations performed on the block device.", el, e.Value)
+	}
+
+	for _, p := range []struct {
+		resource string
+		psi      *types.PSIStats
+	}{{"cpu", s.CPU.PSI}, {"memory", s.Memory.PSI}, {"io", s.Blkio.PSI}} {
+		if p.psi == nil {
+			continue
+		}
+		pl := metricLabels(c, "resource", p.resource)
+		// PSI totals are in microseconds.
+		w.seconds("runc_container_pressure_waiting_seconds", "Total time some tasks were stalled on the resource.", pl, p.psi.Some.Total*1000)
+		w.seconds("runc_container_pressure_stalled_seconds", "Total time all non-idle tasks were stalled on the resource.", pl, p.psi.Full.Total*1000)
+	}
+
+	pageSizes := make([]string, 0, len(s.Hugetlb))
+	for k := range s.Hugetlb {
+		pageSizes = append(pageSizes, k)
+	}
+	sort.Strings(pageSizes)
+	for _, k := range pageSizes {
+		h := s.Hugetlb[k]
+		hl := metricLabels(c, "pagesize", k)
+		w.gauge("runc_container_hugetlb_usage_bytes", "Current huge pages usage.", hl, h.Usage)
+		w.gauge("runc_container_hugetlb_max_usage_bytes", "Maximum huge pages usage recorded.", hl, h.Max)
+		w.counter("runc_container_hugetlb_failcnt", "Number of times the huge pages limit was hit.", hl, h.Failcnt)
+	}
+
+	for _, i := range s.NetworkInterfaces {
+		il := metricLabels(c, "interface", i.Name)
+		w.counter("runc_container_network_receive_bytes", "Number of bytes received.", il, i.RxBytes)
+		w.counter("runc_container_network_receive_packets", "Number of packets received.", il, i.RxPackets)
+		w.counter("runc_container_network_receive_errors", "Number of errors while receiving.", il, i.RxErrors)
+		w.counter("runc_container_network_receive_dropped", "Number of packets dropped while receiving.", il, i.RxDropped)
+		w.counter("runc_container_network_transmit_bytes", "Number of bytes transmitted.", il, i.TxBytes)
+		w.counter("runc_container_network_transmit_packets", "Number of packets transmitted.", il, i.TxPackets)
+		w.counter("runc_container_network_transmit_errors", "Number of errors while transmitting.", il, i.TxErrors)
+		w.counter("runc_container_network_transmit_dropped", "Number of packets dropped while transmitting.", il, i.TxDropped)
+	}
+
+	if s.IntelRdt.MBMStats != nil {
+		for node, m := range *s.IntelRdt.MBMStats {
+			nl := metricLabels(c, "numa_node", strconv.Itoa(node))
+			w.counter("runc_container_intel_rdt_mbm_total_bytes", "Memory bandwidth used, from Intel RDT memory bandwidth monitoring.", nl, m.MBMTotalBytes)
+			w.counter("runc_container_intel_rdt_mbm_local_bytes", "Local memory bandwidth used, from Intel RDT memory bandwidth monitoring.", nl, m.MBMLocalBytes)
+		}
+	}
+	if s.IntelRdt.CMTStats != nil {
+		for node, m := range *s.IntelRdt.CMTStats {
+			nl := metricLabels(c, "numa_node", strconv.Itoa(node))
+			w.gauge("runc_container_intel_rdt_llc_occupancy_bytes", "Last level cache occupancy, from Intel RDT cache monitoring.", nl, m.LLCOccupancy)
+		}
+	}
+}
@@ -8,20 +8,33 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/docker/go-units"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+
+	"github.com/opencontainers/runc/libcontainer"
 )
 
 var psCommand = cli.Command{
 	Name:      "ps",
 	Usage:     "ps displays the processes running inside a container",
 	ArgsUsage: `<container-id> [ps options]`,
+	Description: `The ps command lists the processes of the container, as read from /proc.
+
+If ps options are given, the host ps(1) is run with these options instead, and
+its output is filtered to only contain the processes of the container.`,
 	Flags: []cli.Flag{
 		cli.StringFlag{
 			Name:  "format, f",
 			Value: "table",
-			Usage: `select one of: ` + formatOptions,
+			Usage: `select one of: table, json (an array of pids) or json-detailed`,
+		},
+		cli.BoolFlag{
+			Name:  "tree",
+			Usage: "show the processes as a tree (with the table and json-detailed formats)",
 		},
 	},
 	Action: func(context *cli.Context) error {
@@ -41,61 +54,165 @@ var psCommand = cli.Command{
 			return err
 		}
 
-		pids, err := container.Processes()
-		if err != nil {
-			return err
-		}
-
-		switch context.String("format") {
-		case "table":
-		case "json":
-			return json.NewEncoder(os.Stdout).Encode(pids)
-		default:
-			return errors.New("invalid format option")
-		}
-
 		// [1:] is to remove command name, ex:
 		// context.Args(): [container_id ps_arg1 ps_arg2 ...]
 		// psArgs:         [ps_arg1 ps_arg2 ...]
 		//
 		psArgs := context.Args()[1:]
-		if len(psArgs) == 0 {
-			psArgs = []string{"-ef"}
+		tree := context.Bool("tree")
+		format := context.String("format")
+		switch format {
+		case "table", "json-detailed":
+		case "json":
+			if tree {
+				return errors.New("--tree can not be used with the json format")
+			}
+			pids, err := container.Processes()
+			if err != nil {
+				return err
+			}
+			return json.NewEncoder(os.Stdout).Encode(pids)
+		default:
+			return errors.New("invalid format option")
 		}
 
-		cmd := exec.Command("ps", psArgs...)
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("%w: %s", err, output)
+		if len(psArgs) > 0 {
+			if format != "table" || tree {
+				return errors.New("ps options can only be used with the table format, without --tree")
+			}
+			pids, err := container.Processes()
+			if err != nil {
+				return err
+			}
+			return hostPs(pids, psArgs)
 		}
 
-		lines := strings.Split(string(output), "\n")
-		pidIndex, err := getPidIndex(lines[0])
+		procs, err := container.ListProcesses()
 		if err != nil {
 			return err
 		}
+		if format == "table" {
+			return printProcesses(procs, tree)
+		}
+		if tree {
+			return json.NewEncoder(os.Stdout).Encode(processTree(procs))
+		}
+		if procs == nil {
+			procs = []libcontainer.ProcessInfo{}
+		}
+		return json.NewEncoder(os.Stdout).Encode(procs)
+	},
+	SkipArgReorder: true,
+}
 
-		fmt.Println(lines[0])
-		for _, line := range lines[1:] {
-			if len(line) == 0 {
-				continue
-			}
-			fields := strings.Fields(line)
-			p, err := strconv.Atoi(fields[pidIndex])
-			if err != nil {
-				return fmt.Errorf("unable to parse pid: %w", err)
-			}
+// psTreeNode is a process along with its child processes, as printed by
+// runc ps --tree.
+type psTreeNode struct {
+	libcontainer.ProcessInfo
+	Children []*psTreeNode `json:"children,omitempty"`
+}
+
+// processTree arranges procs (sorted by pid) as trees, whose roots are the
+// processes whose parent is not in procs.
+func processTree(procs []libcontainer.ProcessInfo) []*psTreeNode {
+	nodes := make(map[int]*psTreeNode, len(procs))
+	for _, p := range procs {
+		nodes[p.Pid] = &psTreeNode{ProcessInfo: p}
+	}
+	roots := []*psTreeNode{}
+	for _, p := range procs {
+		n := nodes[p.Pid]
+		if parent, ok := nodes[p.PPid]; ok && p.PPid != p.Pid {
+			parent.Children = append(parent.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	return roots
+}
+
+func printProcesses(procs []libcontainer.ProcessInfo, tree bool) error {
+	w := tabwriter.NewWriter(os.Stdout, 8, 1, 3, ' ', 0)
+	fmt.Fprint(w, "PID\tPPID\tNSPID\tUSER\tSTATE\tRSS\tTIME\tCGROUP\tCMD\n")
+	var print func(n *psTreeNode, depth int)
+	print = func(n *psTreeNode, depth int) {
+		p := n.ProcessInfo
+		nsPid, user, cmd := "-", p.User, strings.Join(p.Cmdline, " ")
+		if p.NsPid != 0 {
+			nsPid = strconv.Itoa(p.NsPid)
+		}
+		if user == "" {
+			user = strconv.Itoa(p.UID)
+		}
+		if cmd == "" {
+			cmd = "[" + p.Name + "]"
+		}
+		if depth > 0 {
+			cmd = strings.Repeat("    ", depth-1) + " \\_ " + cmd
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			p.Pid, p.PPid, nsPid, user, p.State,
+			units.BytesSize(float64(p.RSS)), formatCPUTime(p.CPUTime), p.Cgroup, cmd)
+		for _, c := range n.Children {
+			print(c, depth+1)
+		}
+	}
+	if tree {
+		for _, n := range processTree(procs) {
+			print(n, 0)
+		}
+	} else {
+		for _, p := range procs {
+			print(&psTreeNode{ProcessInfo: p}, 0)
+		}
+	}
+	return w.Flush()
+}
+
+// formatCPUTime formats d as [DD-]HH:MM:SS, like ps(1).
+func formatCPUTime(d time.Duration) string {
+	s := int64(d / time.Second)
+	days, h, m := s/86400, s/3600%24, s/60%60
+	if days > 0 {
+		return fmt.Sprintf("%d-%02d:%02d:%02d", days, h, m, s%60)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s%60)
+}
+
+// hostPs runs the host ps(1) with psArgs, and only prints the lines of its
+// output about the processes in pids.
+func hostPs(pids []int, psArgs []string) error {
+	cmd := exec.Command("ps", psArgs...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+
+	lines := strings.Split(string(output), "\n")
+	pidIndex, err := getPidIndex(lines[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(lines[0])
+	for _, line := range lines[1:] {
+		if len(line) == 0 {
+			continue
+		}
+		fields := strings.Fields(line)
+		p, err := strconv.Atoi(fields[pidIndex])
+		if err != nil {
+			return fmt.Errorf("unable to parse pid: %w", err)
+		}
 
-			for _, pid := range pids {
-				if pid == p {
-					fmt.Println(line)
-					break
-				}
+		for _, pid := range pids {
+			if pid == p {
+				fmt.Println(line)
+				break
 			}
 		}
-		return nil
-	},
-	SkipArgReorder: true,
+	}
+	return nil
 }
 
 func getPidIndex(title string) (int, error) {
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/containerd/console"
+	"github.com/sirupsen/logrus"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+// attachSocketFilename is the name of the unix socket in the container state
+// directory on which the container monitor serves the container's terminal
+// or stdio, when the container is created with --attachable.
+const attachSocketFilename = "attach.sock"
+
+// Frame types of the attach protocol. Every message sent over the attach
+// socket, in either direction, is a frame made of a type byte, the payload
+// length as a big endian uint32, and the payload.
+const (
+	// frameHello is the first frame sent by each side. The client sends
+	// an attachHello, and the relay replies with an attachHelloReply.
+	frameHello byte = iota
+	// frameStdin carries data to be written to the container's stdin (or
+	// terminal). Only accepted from a client which is not read-only.
+	frameStdin
+	// frameStdout and frameStderr carry the container output. If the
+	// container has a terminal, all of its output is sent as frameStdout.
+	frameStdout
+	frameStderr
+	// frameResize carries the new terminal size, as two big endian uint16
+	// (height and width).
+	frameResize
+	// frameExit is the last frame sent by the relay once the container's
+	// init has exited, and carries its exit status as a big endian uint32.
+	frameExit
+)
+
+// maxFrameSize is the maximum payload size of an attach protocol frame.
+const maxFrameSize = 1 << 20
+
+// attachQueueLen is the number of output frames queued for a client before
+// it is considered too slow and disconnected. The client queue has room for
+// one more frame, which is reserved for the exit frame.
+const attachQueueLen = 256
+
+type attachHello struct {
+	ReadOnly bool `json:"read_only,omitempty"`
+}
+
+type attachHelloReply struct {
+	Terminal bool   `json:"terminal,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	_, err := w.Write(encodeFrame(typ, payload))
+	return err
+}
+
+func encodeFrame(typ byte, payload []byte) []byte {
+	buf := make([]byte, 5+len(payload))
+	buf[0] = typ
+	binary.BigEndian.PutUint32(buf[1:5], uint32(len(payload)))
+	copy(buf[5:], payload)
+	return buf
+}
+
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(hdr[1:5])
+	if size > maxFrameSize {
+		return 0, nil, fmt.Errorf("attach frame too large (%d bytes)", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return hdr[0], payload, nil
+}
+
+// attachRelay is run by the container monitor. It keeps the container's
+// terminal master or stdio pipes, and relays them to the clients connected
+// to the attach socket. Any number of read-only clients can be attached at
+// the same time, but only one client which can write to the container.
+type attachRelay struct {
+	listener *net.UnixListener
+	path     string
+
+	mu       sync.Mutex
+	terminal bool
+	console  console.Console
+	stdin    io.Writer
+	clients  map[*attachClient]struct{}
+	writer   *attachClient
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+type attachClient struct {
+	conn     net.Conn
+	readOnly bool
+	// out is the queue of encoded frames to be sent to the client.
+	out chan []byte
+}
+
+// newAttachRelay creates the attach socket at path, and starts accepting
+// clients.
+func newAttachRelay(path string) (*attachRelay, error) {
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	a := &attachRelay{
+		listener: l,
+		path:     path,
+		clients:  make(map[*attachClient]struct{}),
+	}
+	go a.serve()
+	return a, nil
+}
+
+// setupIO sets up the process stdio (or terminal) to be relayed to the
+// attach clients. If l is not nil, the container's stdout and stderr are
+// also written to it.
+func (a *attachRelay) setupIO(p *libcontainer.Process, rootuid, rootgid int, createTTY bool, l *stdioLog) (*tty, error) {
+	if createTTY {
+		p.Stdin = nil
+		p.Stdout = nil
+		p.Stderr = nil
+		parent, child, err := utils.NewSockPair("console")
+		if err != nil {
+			return nil, err
+		}
+		p.ConsoleSocket = child
+		t := &tty{
+			postStart: []io.Closer{parent, child},
+			consoleC:  make(chan error, 1),
+		}
+		t.wg.Add(1)
+		go func() {
+			cons, err := a.recvConsole(parent)
+			t.consoleC <- err
+			if err != nil {
+				t.wg.Done()
+				return
+			}
+			a.relayOutput(&t.wg, frameStdout, cons, nil)
+		}()
+		return t, nil
+	}
+
+	i, err := p.InitializeIO(rootuid, rootgid)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	a.stdin = i.Stdin
+	a.mu.Unlock()
+	t := &tty{
+		closers: []io.Closer{i.Stdin},
+	}
+	for _, cc := range []interface{}{
+		p.Stdin,
+		p.Stdout,
+		p.Stderr,
+	} {
+		if c, ok := cc.(io.Closer); ok {
+			t.postStart = append(t.postStart, c)
+		}
+	}
+	var stdoutLog, stderrLog io.WriteCloser
+	if l != nil {
+		stdoutLog, stderrLog = l.stream("stdout"), l.stream("stderr")
+	}
+	t.wg.Add(2)
+	go a.relayOutput(&t.wg, frameStdout, i.Stdout, stdoutLog)
+	go a.relayOutput(&t.wg, frameStderr, i.Stderr, stderrLog)
+	return t, nil
+}
+
+// recvConsole receives the terminal master from the container's init.
+func (a *attachRelay) recvConsole(socket *os.File) (console.Console, error) {
+	f, err := utils.RecvFd(socket)
+	if err != nil {
+		return nil, err
+	}
+	cons, err := console.ConsoleFromFile(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := console.ClearONLCR(cons.Fd()); err != nil {
+		cons.Close()
+		return nil, err
+	}
+	a.mu.Lock()
+	a.terminal = true
+	a.console = cons
+	a.stdin = cons
+	a.mu.Unlock()
+	return cons, nil
+}
+
+// relayOutput reads the container output from r until EOF (or, for a
+// terminal, EIO), and sends it to the attach clients and the log, if any.
+// The output is read even if no clients are attached, so that the container
+// never blocks writing it.
+func (a *attachRelay) relayOutput(wg *sync.WaitGroup, typ byte, r io.ReadCloser, log io.WriteCloser) {
+	defer wg.Done()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			a.broadcast(encodeFrame(typ, buf[:n]))
+			if log != nil {
+				_, _ = log.Write(buf[:n])
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if log != nil {
+		if err := log.Close(); err != nil {
+			logrus.Warnf("unable to write container output to log: %v", err)
+		}
+	}
+	_ = r.Close()
+}
+
+func (a *attachRelay) broadcast(frame []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for c := range a.clients {
+		// Frames are only queued with a.mu held, so this can't block.
+		if len(c.out) < attachQueueLen {
+			c.out <- frame
+			continue
+		}
+		// Do not let a slow client hold up the container.
+		logrus.Debug("attach client is too slow, disconnecting")
+		a.removeLocked(c)
+	}
+}
+
+func (a *attachRelay) serve() {
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			return
+		}
+		go a.handle(conn)
+	}
+}
+
+// handle runs the protocol with a newly connected client.
+func (a *attachRelay) handle(conn net.Conn) {
+	var hello attachHello
+	typ, payload, err := readFrame(conn)
+	if err == nil && typ != frameHello {
+		err = errors.New("expected a hello frame")
+	}
+	if err == nil {
+		err = json.Unmarshal(payload, &hello)
+	}
+	if err != nil {
+		logrus.Debugf("attach: %v", err)
+		conn.Close()
+		return
+	}
+
+	c := &attachClient{
+		conn:     conn,
+		readOnly: hello.ReadOnly,
+		out:      make(chan []byte, attachQueueLen+1),
+	}
+	a.mu.Lock()
+	var reply attachHelloReply
+	switch {
+	case a.closed:
+		reply.Error = "container has exited"
+	case !c.readOnly && a.writer != nil:
+		reply.Error = "another client is attached with write access"
+	default:
+		reply.Terminal = a.terminal
+		a.clients[c] = struct{}{}
+		if !c.readOnly {
+			a.writer = c
+		}
+		a.wg.Add(1)
+		go c.send(&a.wg)
+	}
+	data, _ := json.Marshal(reply)
+	if reply.Error != "" {
+		a.mu.Unlock()
+		_ = writeFrame(conn, frameHello, data)
+		conn.Close()
+		return
+	}
+	c.out <- encodeFrame(frameHello, data)
+	a.mu.Unlock()
+
+	for {
+		typ, payload, err := readFrame(conn)
+		if err != nil {
+			break
+		}
+		if c.readOnly {
+			continue
+		}
+		switch typ {
+		case frameStdin:
+			a.mu.Lock()
+			stdin := a.stdin
+			a.mu.Unlock()
+			if stdin != nil {
+				_, _ = stdin.Write(payload)
+			}
+		case frameResize:
+			if len(payload) != 4 {
+				continue
+			}
+			a.mu.Lock()
+			cons := a.console
+			a.mu.Unlock()
+			if cons != nil {
+				_ = cons.Resize(console.WinSize{
+					Height: binary.BigEndian.Uint16(payload[0:2]),
+					Width:  binary.BigEndian.Uint16(payload[2:4]),
+				})
+			}
+		}
+	}
+	a.mu.Lock()
+	a.removeLocked(c)
+	a.mu.Unlock()
+}
+
+// removeLocked disconnects the client, once its queued frames are sent.
+// Must be called with a.mu held.
+func (a *attachRelay) removeLocked(c *attachClient) {
+	if _, ok := a.clients[c]; !ok {
+		return
+	}
+	delete(a.clients, c)
+	if a.writer == c {
+		a.writer = nil
+	}
+	close(c.out)
+}
+
+func (c *attachClient) send(wg *sync.WaitGroup) {
+	defer wg.Done()
+	for frame := range c.out {
+		if _, err := c.conn.Write(frame); err != nil {
+			break
+		}
+	}
+	c.conn.Close()
+	// Drain the queue if the client went away.
+	for range c.out {
+	}
+}
+
+// close sends the exit status of the container's init to the clients,
+// disconnects them, and removes the attach socket.
+func (a *attachRelay) close(exitStatus int) {
+	a.mu.Lock()
+	a.closed = true
+	a.listener.Close()
+	var status [4]byte
+	binary.BigEndian.PutUint32(status[:], uint32(exitStatus))
+	frame := encodeFrame(frameExit, status[:])
+	for c := range a.clients {
+		// Give the clients some time to read the remaining output. There
+		// is always room for the exit frame (see attachQueueLen).
+		_ = c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		c.out <- frame
+		a.removeLocked(c)
+	}
+	a.mu.Unlock()
+	a.wg.Wait()
+	_ = os.Remove(a.path)
+}
@@ -65,6 +65,31 @@ command(s) that get executed on start, edit the args parameter of the spec. See
 			Name:  "no-new-keyring",
 			Usage: "do not create a new session keyring for the container.  This will cause the container to inherit the calling processes session key",
 		},
+		cli.BoolFlag{
+			Name:  "init",
+			Usage: "run a minimal init as the container's pid 1, which reaps zombie processes and forwards signals to the container process",
+		},
+		cli.BoolFlag{
+			Name:  "monitor",
+			Usage: "keep a runc process as the parent of the container's init to record its exit status (requires --detach)",
+		},
+		cli.BoolFlag{
+			Name:  "capture-stdio",
+			Usage: "write the container's stdout and stderr to a log file to be read with 'runc logs' (implies --monitor)",
+		},
+		cli.StringFlag{
+			Name:  "stdio-log-max-size",
+			Usage: "maximum size of the stdio log file before it is rotated (default: 10M)",
+		},
+		cli.IntFlag{
+			Name:  "stdio-log-max-files",
+			Value: defaultStdioLogMaxFiles,
+			Usage: "maximum number of stdio log files to keep, including the current one",
+		},
+		cli.BoolFlag{
+			Name:  "attachable",
+			Usage: "serve the container's terminal or stdio to 'runc attach' clients (implies --monitor)",
+		},
 		cli.IntFlag{
 			Name:  "preserve-fds",
 			Usage: "Pass N additional file descriptors to the container (stdio + $LISTEN_FDS + N in total)",
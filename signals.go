@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"os/signal"
 
@@ -37,10 +38,10 @@ func newSignalHandler(enableSubreaper bool, notifySocket *notifySocket) *signalH
 }
 
 // exit models a process exit status with the pid and
-// exit status.
+// wait status.
 type exit struct {
 	pid    int
-	status int
+	status unix.WaitStatus
 }
 
 type signalHandler struct {
@@ -49,8 +50,9 @@ type signalHandler struct {
 }
 
 // forward handles the main signal event loop forwarding, resizing, or reaping depending
-// on the signal received.
-func (h *signalHandler) forward(process *libcontainer.Process, tty *tty, detach bool) (int, error) {
+// on the signal received. It returns the wait status of the process once it
+// has exited.
+func (h *signalHandler) forward(process *libcontainer.Process, tty *tty, detach bool) (unix.WaitStatus, error) {
 	// make sure we know the pid of our main process so that we can return
 	// after it dies.
 	if detach && h.notifySocket == nil {
@@ -59,7 +61,7 @@ func (h *signalHandler) forward(process *libcontainer.Process, tty *tty, detach
 
 	pid1, err := process.Pid()
 	if err != nil {
-		return -1, err
+		return 0, err
 	}
 
 	if h.notifySocket != nil {
@@ -88,7 +90,7 @@ func (h *signalHandler) forward(process *libcontainer.Process, tty *tty, detach
 			for _, e := range exits {
 				logrus.WithFields(logrus.Fields{
 					"pid":    e.pid,
-					"status": e.status,
+					"status": utils.ExitStatus(e.status),
 				}).Debug("process exited")
 				if e.pid == pid1 {
 					// call Wait() on the process even though we already have the exit
@@ -111,7 +113,27 @@ func (h *signalHandler) forward(process *libcontainer.Process, tty *tty, detach
 			}
 		}
 	}
-	return -1, nil
+	return 0, errors.New("signal channel closed")
+}
+
+// waitFor reaps all the children until the process with the given pid exits,
+// and returns its wait status. Unlike forward, it does not forward any signals.
+func (h *signalHandler) waitFor(pid int) (unix.WaitStatus, error) {
+	for s := range h.signals {
+		if s != unix.SIGCHLD {
+			continue
+		}
+		exits, err := h.reap()
+		if err != nil {
+			logrus.Error(err)
+		}
+		for _, e := range exits {
+			if e.pid == pid {
+				return e.status, nil
+			}
+		}
+	}
+	return 0, errors.New("signal channel closed")
 }
 
 // reap runs wait4 in a loop until we have finished processing any existing exits
@@ -134,7 +156,7 @@ func (h *signalHandler) reap() (exits []exit, err error) {
 		}
 		exits = append(exits, exit{
 			pid:    pid,
-			status: utils.ExitStatus(ws),
+			status: ws,
 		})
 	}
 }
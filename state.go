@@ -34,8 +34,10 @@ instance of a container.`,
 			return err
 		}
 		pid := state.BaseState.InitProcessPid
+		var exitStatus *libcontainer.ExitStatus
 		if containerStatus == libcontainer.Stopped {
 			pid = 0
+			exitStatus = state.BaseState.ExitStatus
 		}
 		bundle, annotations := utils.Annotations(state.Config.Labels)
 		cs := containerState{
@@ -47,6 +49,7 @@ instance of a container.`,
 			Rootfs:         state.BaseState.Config.Rootfs,
 			Created:        state.BaseState.Created,
 			Annotations:    annotations,
+			ExitStatus:     exitStatus,
 		}
 		data, err := json.MarshalIndent(cs, "", "  ")
 		if err != nil {
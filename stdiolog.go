@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/docker/go-units"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+
+	"github.com/opencontainers/runc/libcontainer"
+)
+
+const (
+	// stdioLogFilename is the name of the file in the container state
+	// directory to which the container's stdout and stderr are written when
+	// the container is created with --capture-stdio. Rotated files have a
+	// numeric suffix, with the oldest one having the highest number.
+	stdioLogFilename = "stdio.log"
+
+	defaultStdioLogMaxSize  = 10 * units.MiB
+	defaultStdioLogMaxFiles = 3
+
+	// maxStdioLogLine is the size after which a line of output which does
+	// not end with a newline is logged as a separate entry.
+	maxStdioLogLine = 16 * 1024
+)
+
+// stdioLogEntry is a single line of the container output, as stored in the
+// stdio log file (one JSON object per line).
+type stdioLogEntry struct {
+	Log    string    `json:"log"`
+	Stream string    `json:"stream"`
+	Time   time.Time `json:"time"`
+}
+
+// stdioLog writes the container output to a log file, rotating it once it
+// grows larger than maxSize.
+type stdioLog struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// stdioLogOptions parses the stdio log options of the create and run
+// commands. It returns nil if the container output is not to be captured.
+func stdioLogOptions(context *cli.Context) (*stdioLog, error) {
+	if !context.Bool("capture-stdio") {
+		return nil, nil
+	}
+	l := &stdioLog{maxSize: defaultStdioLogMaxSize}
+	if val := context.String("stdio-log-max-size"); val != "" {
+		size, err := units.RAMInBytes(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for stdio-log-max-size: %w", err)
+		}
+		if size <= 0 {
+			return nil, errors.New("stdio-log-max-size must be positive")
+		}
+		l.maxSize = size
+	}
+	if l.maxFiles = context.Int("stdio-log-max-files"); l.maxFiles < 1 {
+		return nil, errors.New("stdio-log-max-files must be at least 1")
+	}
+	return l, nil
+}
+
+// open opens (creating it if needed) the log file at path.
+func (l *stdioLog) open(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.path = path
+	l.file = f
+	l.size = st.Size()
+	return nil
+}
+
+func (l *stdioLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// log writes a log entry for data, which came from the given stream.
+func (l *stdioLog) log(stream string, data []byte) error {
+	entry, err := json.Marshal(stdioLogEntry{
+		Log:    string(data),
+		Stream: stream,
+		Time:   time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	entry = append(entry, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return os.ErrClosed
+	}
+	if l.size > 0 && l.size+int64(len(entry)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(entry)
+	l.size += int64(n)
+	return err
+}
+
+// rotate renames the current log file to path.1 (shifting the older rotated
+// files, and removing the ones exceeding maxFiles), and starts a new one.
+// Must be called with l.mu held.
+func (l *stdioLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	if l.maxFiles > 1 {
+		for i := l.maxFiles - 1; i > 0; i-- {
+			older := l.path + "." + strconv.Itoa(i)
+			if i == l.maxFiles-1 {
+				if err := os.Remove(older); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				continue
+			}
+			if err := os.Rename(older, l.path+"."+strconv.Itoa(i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(l.path); err != nil {
+		return err
+	}
+	return l.open(l.path)
+}
+
+// stream returns a writer which logs the data written to it line by line,
+// tagged with the stream name. It must be closed to log the last line if it
+// does not end with a newline.
+func (l *stdioLog) stream(name string) io.WriteCloser {
+	return &stdioLogStream{log: l, name: name}
+}
+
+type stdioLogStream struct {
+	log  *stdioLog
+	name string
+	buf  []byte
+}
+
+func (s *stdioLogStream) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	for {
+		i := bytes.IndexByte(s.buf, '\n') + 1
+		if i == 0 {
+			if len(s.buf) <= maxStdioLogLine {
+				return len(p), nil
+			}
+			// Split the line, but not in the middle of a UTF-8 encoded
+			// rune (which would be mangled in the JSON log entry).
+			i = maxStdioLogLine
+			for j := i; j > i-utf8.UTFMax; j-- {
+				if utf8.RuneStart(s.buf[j]) {
+					i = j
+					break
+				}
+			}
+		}
+		// Do not stop reading the output on errors, or the container
+		// would block writing to it.
+		if err := s.log.log(s.name, s.buf[:i]); err != nil {
+			logrus.Warnf("unable to write container %s to log: %v", s.name, err)
+		}
+		s.buf = s.buf[i:]
+	}
+}
+
+func (s *stdioLogStream) Close() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	err := s.log.log(s.name, s.buf)
+	s.buf = nil
+	return err
+}
+
+// setupStdioLog sets up the process stdio so that its stdout and stderr are
+// written to the stdio log, and its stdin is empty.
+func setupStdioLog(p *libcontainer.Process, rootuid, rootgid int, l *stdioLog) (*tty, error) {
+	i, err := p.InitializeIO(rootuid, rootgid)
+	if err != nil {
+		return nil, err
+	}
+	_ = i.Stdin.Close()
+	t := &tty{
+		closers: []io.Closer{
+			i.Stdout,
+			i.Stderr,
+		},
+	}
+	for _, cc := range []interface{}{
+		p.Stdin,
+		p.Stdout,
+		p.Stderr,
+	} {
+		if c, ok := cc.(io.Closer); ok {
+			t.postStart = append(t.postStart, c)
+		}
+	}
+	t.wg.Add(2)
+	go t.copyLog(l.stream("stdout"), i.Stdout)
+	go t.copyLog(l.stream("stderr"), i.Stderr)
+	return t, nil
+}
+
+func (t *tty) copyLog(w io.WriteCloser, r io.ReadCloser) {
+	defer t.wg.Done()
+	_, _ = io.Copy(w, r)
+	if err := w.Close(); err != nil {
+		logrus.Warnf("unable to write container output to log: %v", err)
+	}
+	_ = r.Close()
+}
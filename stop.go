@@ -0,0 +1,64 @@
+package main
+
+import (
+	gocontext "context"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+var stopCommand = cli.Command{
+	Name:  "stop",
+	Usage: "stop a container, killing it if it does not exit in time",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is the name for the instance of the container.
+
+EXAMPLE:
+For example, to give the "ubuntu01" container 30 seconds to exit after
+receiving SIGTERM, before all of its processes are killed:
+
+       # runc stop --timeout 30 ubuntu01`,
+	Description: `The stop command sends a signal (SIGTERM by default) to the container's init
+process, and waits for it to exit. If it is still running after the timeout,
+all the processes of the container are killed with SIGKILL.
+
+Once the container is stopped, the step which has ended it is printed: either
+"signaled", "killed", or "not running" if the container was already stopped.`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "timeout, t",
+			Value: 10,
+			Usage: "seconds to wait for the container to exit before killing it",
+		},
+		cli.StringFlag{
+			Name:  "signal, s",
+			Value: "SIGTERM",
+			Usage: "signal to send to the container's init process",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		if context.Int("timeout") < 0 {
+			return fmt.Errorf("invalid timeout: %d", context.Int("timeout"))
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		signal, err := parseSignal(context.String("signal"))
+		if err != nil {
+			return err
+		}
+		timeout := time.Duration(context.Int("timeout")) * time.Second
+		step, err := container.Stop(gocontext.Background(), signal, timeout)
+		if err != nil {
+			return err
+		}
+		fmt.Println(step)
+		return nil
+	},
+}
@@ -0,0 +1,82 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc attach [stdio]" {
+	update_config '.process.terminal = false | .process.args = ["cat"]'
+
+	runc run -d --attachable test_attach
+	[ "$status" -eq 0 ]
+
+	# The container stdin is kept open after the client is gone.
+	for word in hello world; do
+		run timeout 1 sh -c "echo $word | __runc attach test_attach"
+		[ "$status" -eq 124 ]
+		[[ "$output" == "$word" ]]
+	done
+}
+
+@test "runc attach [read-only viewers]" {
+	update_config '.process.terminal = false | .process.args = ["sh", "-c", "read line; echo $line; exit 3"]'
+
+	runc run -d --attachable test_attach
+	[ "$status" -eq 0 ]
+
+	__runc attach --read-only test_attach >"$ROOT/viewer1" &
+	viewer1=$!
+	__runc attach -r test_attach >"$ROOT/viewer2" &
+	viewer2=$!
+	sleep 0.5
+
+	# Only one client can write to the container.
+	(
+		sleep 1
+		echo foo
+	) | __runc attach test_attach >/dev/null &
+	sleep 0.5
+	runc attach test_attach </dev/null
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"another client is attached with write access"* ]]
+
+	# The viewers exit with the container's exit status.
+	run wait $viewer1
+	[ "$status" -eq 3 ]
+	run wait $viewer2
+	[ "$status" -eq 3 ]
+	[[ "$(cat "$ROOT/viewer1")" == "foo" ]]
+	[[ "$(cat "$ROOT/viewer2")" == "foo" ]]
+}
+
+@test "runc attach [terminal]" {
+	update_config '.process.args = ["sh"]'
+
+	# No --console-socket needed.
+	runc run -d --attachable test_attach
+	[ "$status" -eq 0 ]
+
+	# Detach with a custom key sequence (ctrl-a, x).
+	run timeout 5 sh -c "(echo 'echo \$((40+2))'; sleep 1; printf '\001x') | __runc attach --detach-keys ctrl-a,x test_attach"
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"42"* ]]
+
+	testcontainer test_attach running
+}
+
+@test "runc attach [not attachable]" {
+	update_config '.process.terminal = false | .process.args = ["sleep", "10"]'
+
+	runc run -d test_attach </dev/null
+	[ "$status" -eq 0 ]
+
+	runc attach test_attach
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"not attachable"* ]]
+}
@@ -0,0 +1,59 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	requires smp
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc run with final CPU affinity" {
+	update_config '.process.execCPUAffinity = {"initial": "0", "final": "1"}
+		| .process.args = ["grep", "Cpus_allowed_list", "/proc/self/status"]'
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"Cpus_allowed_list:"*[[:space:]]"1" ]]
+}
+
+@test "runc create with initial CPU affinity" {
+	update_config '.process.execCPUAffinity = {"initial": "1"}'
+
+	runc create --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	# Until it is started, runc init runs with the initial affinity.
+	pid=$(__runc state test_busybox | jq '.pid')
+	for t in /proc/"$pid"/task/*; do
+		grep -E "^Cpus_allowed_list:[[:space:]]+1$" "$t"/status
+	done
+}
+
+@test "runc exec with CPU affinity" {
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	proc='
+{
+	"args": ["grep", "Cpus_allowed_list", "/proc/self/status"],
+	"cwd": "/",
+	"execCPUAffinity": {"initial": "1", "final": "0"}
+}'
+	runc exec -p <(echo "$proc") test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"Cpus_allowed_list:"*[[:space:]]"0" ]]
+}
+
+@test "runc run with CPU affinity outside of cpuset" {
+	requires cgroups_cpuset
+	update_config '.process.execCPUAffinity = {"final": "1"}
+		| .linux.resources.cpu.cpus = "0"'
+
+	runc run test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"final CPU affinity is not a subset of the cpuset cpus (0)"* ]]
+}
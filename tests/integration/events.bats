@@ -27,6 +27,62 @@ function teardown() {
 	[[ "${lines[0]}" == *"data"* ]]
 }
 
+@test "events --stats --format openmetrics" {
+	# XXX: currently cgroups require root containers.
+	requires root
+	init_cgroup_paths
+
+	update_config '.annotations = {"org.example.team": "runc"}'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc events --stats --format openmetrics test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *'# TYPE runc_container_pids_current gauge'* ]]
+	[[ "$output" == *'runc_container_pids_current{id="test_busybox",annotation_org_example_team="runc"} 1'* ]]
+	[[ "$output" == *'runc_container_cpu_usage_seconds_total{id="test_busybox",'* ]]
+	[ "${lines[-1]}" = "# EOF" ]
+}
+
+@test "events --stats with psi data" {
+	requires root cgroups_v2 psi
+	init_cgroup_paths
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc events --stats test_busybox
+	[ "$status" -eq 0 ]
+	for resource in cpu memory blkio; do
+		echo "$output" | jq -e ".data.$resource.psi.some | has(\"avg10\") and has(\"avg60\") and has(\"avg300\") and has(\"total\")"
+		echo "$output" | jq -e ".data.$resource.psi.full.total >= 0"
+	done
+
+	runc events --stats --format openmetrics test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *'runc_container_pressure_waiting_seconds_total{id="test_busybox",resource="memory"} '* ]]
+}
+
+@test "metrics --listen" {
+	# XXX: currently cgroups require root containers.
+	requires root
+	init_cgroup_paths
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	__runc metrics --listen "$ROOT/metrics.sock" &
+	local pid=$!
+	retry 10 0.5 test -S "$ROOT/metrics.sock"
+
+	run curl -sS --unix-socket "$ROOT/metrics.sock" http://localhost/metrics
+	kill "$pid"
+	[ "$status" -eq 0 ]
+	[[ "$output" == *'runc_container_pids_current{id="test_busybox"} 1'* ]]
+	[[ "$output" == *'runc_container_memory_usage_bytes{id="test_busybox"} '* ]]
+	[ "${lines[-1]}" = "# EOF" ]
+}
+
 function test_events() {
 	# XXX: currently cgroups require root containers.
 	requires root
@@ -48,14 +104,15 @@ function test_events() {
 	# 2. Waits for an event that includes test_busybox then kills the
 	#    test_busybox container which causes the event logger to exit.
 	(
-		retry 10 "$retry_every" grep -q test_busybox events.log
+		retry 10 "$retry_every" grep -q '"type":"stats"' events.log
 		__runc delete -f test_busybox
 	) &
 	wait # for both subshells to finish
 
 	[ -e events.log ]
 
-	output=$(head -1 events.log)
+	# The lifecycle events come first.
+	output=$(grep -m1 '"type":"stats"' events.log)
 	[[ "$output" == [\{]"\"type\""[:]"\"stats\""[,]"\"id\""[:]"\"test_busybox\""[,]* ]]
 	[[ "$output" == *"data"* ]]
 }
@@ -98,5 +155,131 @@ function test_events() {
 	) &
 	wait # wait for the above sub shells to finish
 
-	grep -q '{"type":"oom","id":"test_busybox"}' events.log
+	grep -q '{"type":"oom","id":"test_busybox",' events.log
+}
+
+@test "events memory.high" {
+	requires root cgroups_v2
+	init_cgroup_paths
+
+	update_config '(.. | select(.resources? != null)) .resources.unified |= {"memory.high": "16777216"}'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc events --stats test_busybox
+	[ "$status" -eq 0 ]
+	echo "$output" | jq -e '.data.memory.events | has("high") and has("max") and has("oom_kill")'
+
+	(__runc events --interval 1h test_busybox >events.log) &
+	(
+		retry 10 0.1 grep -q '"type":"started"' events.log
+		# shellcheck disable=SC2016
+		__runc exec -d test_busybox sh -c 'test=$(dd if=/dev/zero ibs=32M count=1)'
+		retry 10 1 grep -q '"type":"memory.high"' events.log
+		__runc delete -f test_busybox
+	) &
+	wait # wait for the above sub shells to finish
+
+	grep -q '{"type":"memory.high","id":"test_busybox",.*"data":{"low":0,"high":[1-9]' events.log
+	# The processes were throttled rather than killed.
+	[ "$(grep -c '"type":"oom"' events.log)" -eq 0 ]
+}
+
+@test "events pressure" {
+	requires root cgroups_v2 psi
+	init_cgroup_paths
+
+	# Two busy loops on a single CPU stall each other.
+	update_config '(.. | select(.resources? != null)) .resources.cpu |= {"cpus": "0"}'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	(__runc events --interval 1h --pressure cpu:some:100ms/2s test_busybox >events.log) &
+	(
+		retry 10 0.1 grep -q '"type":"started"' events.log
+		__runc exec -d test_busybox sh -c 'while :; do :; done'
+		__runc exec -d test_busybox sh -c 'while :; do :; done'
+		retry 10 1 grep -q '"type":"pressure"' events.log
+		__runc delete -f test_busybox
+	) &
+	wait # wait for the above sub shells to finish
+
+	grep -q '{"type":"pressure","id":"test_busybox",.*"data":{"resource":"cpu","kind":"some","threshold":100000,"window":2000000}}' events.log
+}
+
+@test "events pressure with an invalid trigger" {
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc events --pressure cpu:some:100ms/20s test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"invalid PSI trigger window"* ]]
+}
+
+@test "events lifecycle" {
+	# XXX: currently cgroups require root containers.
+	requires root
+	init_cgroup_paths
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	(__runc events --interval 1h test_busybox >events.log) &
+	(
+		retry 10 0.1 grep -q '"type":"started"' events.log
+		__runc pause test_busybox
+		__runc resume test_busybox
+		__runc exec test_busybox sh -c 'exit 3'
+		# Resume after the second event.
+		__runc events --after-seq 2 --interval 1h test_busybox >events-resumed.log &
+		retry 10 0.1 grep -q '"type":"exec-exited"' events-resumed.log
+		__runc delete -f test_busybox
+		wait
+	) &
+	wait # for both subshells to finish
+
+	[ "$(jq -r '.type' events.log | tr '\n' ' ')" = "created started paused resumed exec-started exec-exited stopped deleted " ]
+	[ "$(jq -r '.seq' events.log | tr '\n' ' ')" = "1 2 3 4 5 6 7 8 " ]
+	output=$(jq -r 'select(.type == "exec-exited") | .data.exit_code' events.log)
+	[ "$output" = "3" ]
+	output=$(jq -r '.timestamp' events.log)
+	[[ "$output" != *null* ]]
+
+	[ "$(jq -r '.seq' events-resumed.log | tr '\n' ' ')" = "3 4 5 6 7 8 " ]
+}
+
+@test "events lifecycle [stopped container]" {
+	update_config '.process.terminal = false | .process.args = ["sh", "-c", "exit 5"]'
+	runc run --keep test_busybox
+	[ "$status" -eq 5 ]
+
+	(__runc events test_busybox >events.log) &
+	(
+		retry 10 0.1 grep -q '"type":"stopped"' events.log
+		__runc delete test_busybox
+	) &
+	wait
+
+	[ "$(jq -r '.type' events.log | tr '\n' ' ')" = "created started stopped deleted " ]
+	output=$(jq -r 'select(.type == "stopped") | .data.exit_code' events.log)
+	[ "$output" = "5" ]
+}
+
+@test "events lifecycle [detached container]" {
+	update_config '.process.terminal = false | .process.args = ["sh", "-c", "sleep 1; exit 5"]'
+	runc run -d test_busybox
+	[ "$status" -eq 0 ]
+
+	# The exit of init is recorded once it is seen, rather than on delete.
+	__runc events --interval 1h test_busybox >events.log &
+	retry 20 0.2 grep -q '"type":"stopped"' events.log
+	output=$(jq -r 'select(.type == "stopped") | .data.pid' events.log)
+	[ "$output" -gt 0 ]
+	output=$(jq -r 'select(.type == "stopped") | .data.exit_code' events.log)
+	[ "$output" = "5" ]
+
+	runc delete test_busybox
+	[ "$status" -eq 0 ]
+	wait
+	[ "$(jq -r '.type' events.log | tr '\n' ' ')" = "created started stopped deleted " ]
 }
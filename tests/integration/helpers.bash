@@ -410,6 +410,18 @@ function requires() {
 				skip_me=1
 			fi
 			;;
+		timens)
+			if [ ! -e "/proc/self/ns/time" ]; then
+				skip_me=1
+			fi
+			;;
+		psi)
+			# Without CONFIG_PSI, the file does not exist. With PSI disabled
+			# (CONFIG_PSI_DEFAULT_DISABLED without psi=1), reading it fails.
+			if ! cat /proc/pressure/cpu &>/dev/null; then
+				skip_me=1
+			fi
+			;;
 		cgroups_v1)
 			init_cgroup_paths
 			if [ ! -v CGROUP_V1 ]; then
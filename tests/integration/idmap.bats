@@ -0,0 +1,87 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	requires root
+
+	setup_busybox
+
+	# The source of the id-mapped mounts, owned by root on the host.
+	mkdir -p source-{1,2}
+	touch source-{1,2}/foo.txt
+	chown -R 0:0 source-{1,2}
+	chmod 755 source-{1,2}
+
+	mkdir -p rootfs/tmp/mount-{1,2}
+
+	update_config ' .linux.namespaces += [{"type": "user"}]
+		| .linux.uidMappings += [{"hostID": 100000, "containerID": 0, "size": 65534}]
+		| .linux.gidMappings += [{"hostID": 100000, "containerID": 0, "size": 65534}] '
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "idmap mount to the container userns" {
+	update_config ' .process.args = ["sh", "-c", "stat -c =%u=%g= /tmp/mount-1/foo.txt"]
+		| .mounts += [{"source": "source-1/", "destination": "/tmp/mount-1", "options": ["bind", "idmap"]}] '
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"=0=0="* ]]
+}
+
+@test "idmap mount with its own mappings" {
+	update_config ' .process.args = ["sh", "-c", "stat -c =%u=%g= /tmp/mount-1/foo.txt"]
+		| .mounts += [{
+			"source": "source-1/",
+			"destination": "/tmp/mount-1",
+			"options": ["bind"],
+			"uidMappings": [{"containerID": 0, "hostID": 100005, "size": 1}],
+			"gidMappings": [{"containerID": 0, "hostID": 100007, "size": 1}]
+		}] '
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"=5=7="* ]]
+}
+
+@test "idmap mount writes as the mapped owner" {
+	update_config ' .process.args = ["sh", "-c", "touch /tmp/mount-1/bar.txt"]
+		| .mounts += [{"source": "source-1/", "destination": "/tmp/mount-1", "options": ["bind", "idmap"]}] '
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(stat -c %u:%g source-1/bar.txt)" = "0:0" ]
+}
+
+@test "idmap mount along with a regular mount" {
+	update_config ' .process.args = ["sh", "-c", "stat -c =%u=%g= /tmp/mount-1/foo.txt /tmp/mount-2/foo.txt"]
+		| .mounts += [
+			{"source": "source-1/", "destination": "/tmp/mount-1", "options": ["bind", "idmap"]},
+			{"source": "source-2/", "destination": "/tmp/mount-2", "options": ["bind"]}
+		] '
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	[[ "${lines[0]}" == *"=0=0="* ]]
+	[[ "${lines[1]}" == *"=65534=65534="* ]]
+}
+
+@test "idmap mount of a non-bind mount fails" {
+	update_config ' .mounts += [{"source": "tmpfs", "destination": "/tmp/mount-1", "type": "tmpfs", "options": ["idmap"]}] '
+
+	runc run test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"only bind mounts can be id-mapped"* ]]
+}
+
+@test "idmap mount of an unsupported filesystem fails" {
+	update_config ' .mounts += [{"source": "/proc", "destination": "/tmp/mount-1", "options": ["rbind", "idmap"]}] '
+
+	runc run test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"the filesystem may not support id-mapped mounts"* ]]
+}
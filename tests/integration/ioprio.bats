@@ -0,0 +1,52 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc run with ioprio" {
+	update_config '.process.ioPriority = {"class": "IOPRIO_CLASS_BE", "priority": 6}
+		| .process.args = ["ionice"]'
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"best-effort: prio 6"* ]]
+}
+
+@test "runc exec with ioprio" {
+	update_config '.process.ioPriority = {"class": "IOPRIO_CLASS_BE", "priority": 6}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	# The exec'd processes get the I/O priority of the container process...
+	runc exec test_busybox ionice
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"best-effort: prio 6"* ]]
+
+	# ... unless they have their own, set with --ioprio...
+	runc exec --ioprio rt:2 test_busybox ionice
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"realtime: prio 2"* ]]
+
+	# ... or in process.json.
+	proc='
+{
+	"args": ["ionice"],
+	"cwd": "/",
+	"ioPriority": {"class": "IOPRIO_CLASS_IDLE"}
+}'
+	runc exec -p <(echo "$proc") test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"idle"* ]]
+
+	runc exec --ioprio be:8 test_busybox ionice
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"invalid I/O priority level 8"* ]]
+}
@@ -29,3 +29,40 @@ function teardown() {
 	runc delete test_busybox
 	[ "$status" -eq 0 ]
 }
+
+@test "kill --all KILL [cgroup.kill]" {
+	requires cgroups_v2 root
+	requires_kernel 5.14
+	set_cgroups_path
+
+	# Without a pid namespace, the container processes are not killed
+	# along with init.
+	update_config '	  .linux.namespaces -= [{"type": "pid"}]
+			| .process.args = ["sh", "-c", "for i in $(seq 100); do sleep 1000 & done; wait"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	retry 10 0.5 sh -c "[ \$(wc -l <$CGROUP_PATH/cgroup.procs) -gt 100 ]"
+
+	runc kill --all test_busybox KILL
+	[ "$status" -eq 0 ]
+	wait_for_container 10 1 test_busybox stopped
+	retry 10 0.5 sh -c "[ -z \"\$(cat $CGROUP_PATH/cgroup.procs)\" ]"
+}
+
+@test "delete --force [cgroup.kill]" {
+	requires cgroups_v2 root
+	requires_kernel 5.14
+	set_cgroups_path
+
+	update_config '	  .linux.namespaces -= [{"type": "pid"}]
+			| .process.args = ["sh", "-c", "for i in $(seq 100); do sleep 1000 & done; wait"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	retry 10 0.5 sh -c "[ \$(wc -l <$CGROUP_PATH/cgroup.procs) -gt 100 ]"
+
+	runc delete --force test_busybox
+	[ "$status" -eq 0 ]
+	[ ! -d "$CGROUP_PATH" ]
+}
@@ -0,0 +1,78 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+	update_config '.process.terminal = false'
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc logs" {
+	update_config '.process.args = ["sh", "-c", "echo out; echo err >&2; printf last"]'
+
+	runc run -d --capture-stdio test_logs
+	[ "$status" -eq 0 ]
+
+	runc wait test_logs
+	[ "$status" -eq 0 ]
+
+	runc logs test_logs
+	[ "$status" -eq 0 ]
+	[[ "${lines[0]}" == "out" ]]
+	[[ "$output" == *"err"* ]]
+	[[ "$output" == *"last" ]]
+
+	# stderr goes to stderr
+	out="$(__runc logs test_logs 2>/dev/null)"
+	[[ "$out" != *"err"* ]]
+
+	# The output is older than the start of the test.
+	sleep 1
+	runc logs --since 1s test_logs
+	[ "$status" -eq 0 ]
+	[ -z "$output" ]
+}
+
+@test "runc logs --follow" {
+	update_config '.process.args = ["sh", "-c", "for i in $(seq 10); do echo $i; sleep 0.1; done"]'
+
+	runc run -d --capture-stdio test_logs
+	[ "$status" -eq 0 ]
+
+	runc logs --follow test_logs
+	[ "$status" -eq 0 ]
+	[ "${#lines[@]}" -eq 10 ]
+	[[ "${lines[9]}" == "10" ]]
+}
+
+@test "runc logs [rotation]" {
+	update_config '.process.args = ["sh", "-c", "for i in $(seq 100); do echo $i; done"]'
+
+	runc run -d --capture-stdio --stdio-log-max-size 1K --stdio-log-max-files 2 test_logs
+	[ "$status" -eq 0 ]
+
+	runc wait test_logs
+	[ "$status" -eq 0 ]
+
+	[ -e "$ROOT/state/test_logs/stdio.log.1" ]
+	[ ! -e "$ROOT/state/test_logs/stdio.log.2" ]
+
+	runc logs test_logs
+	[ "$status" -eq 0 ]
+	[[ "${lines[-1]}" == "100" ]]
+	[[ "${lines[0]}" != "1" ]]
+}
+
+@test "runc logs [not captured]" {
+	update_config '.process.args = ["sleep", "10"]'
+	runc run -d test_logs </dev/null
+	[ "$status" -eq 0 ]
+
+	runc logs test_logs
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"not captured"* ]]
+}
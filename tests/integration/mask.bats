@@ -56,3 +56,23 @@ function teardown() {
 	[ "$status" -eq 1 ]
 	[[ "${output}" == *"Operation not permitted"* ]]
 }
+
+@test "mask paths [procfs subset=pid]" {
+	requires_kernel 5.8
+	update_config '(.mounts[] | select(.destination == "/proc")) .options = ["subset=pid", "hidepid=invisible"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	# The masked and read-only paths in /proc do not appear at all, so
+	# nothing is mounted over them.
+	runc exec test_busybox grep -c ' /proc' /proc/self/mountinfo
+	[ "$status" -eq 0 ]
+	[ "$output" = "1" ]
+
+	runc exec test_busybox ls /proc/kcore
+	[ "$status" -eq 1 ]
+
+	runc exec test_busybox ls /proc/self/status
+	[ "$status" -eq 0 ]
+}
@@ -0,0 +1,58 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc run with memory policy" {
+	# The policy of every mapping of the process (as it has no per-mapping
+	# policy) is the process one.
+	update_config '.linux.memoryPolicy = {"mode": "MPOL_BIND", "nodes": "0", "flags": ["MPOL_F_STATIC_NODES"]}
+		| .process.args = ["sh", "-c", "cut -d \" \" -f 2 /proc/self/numa_maps | sort -u"]'
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == "bind=static:0"* ]]
+	[ "${#lines[@]}" -eq 1 ]
+}
+
+@test "runc exec with memory policy" {
+	update_config '.linux.memoryPolicy = {"mode": "MPOL_INTERLEAVE", "nodes": "0"}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc exec test_busybox sh -c 'cut -d " " -f 2 /proc/self/numa_maps | sort -u'
+	[ "$status" -eq 0 ]
+	[ "$output" = "interleave:0" ]
+}
+
+@test "runc run with invalid memory policy" {
+	update_config '.linux.memoryPolicy = {"mode": "MPOL_BIND"}'
+
+	runc run test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"invalid memory policy: nodes are required"* ]]
+
+	update_config '.linux.memoryPolicy = {"mode": "MPOL_FOO"}'
+
+	runc run test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"memory policy mode \"MPOL_FOO\" is not supported"* ]]
+}
+
+@test "runc run with memory policy outside of cpuset" {
+	requires cgroups_cpuset
+	update_config '.linux.memoryPolicy = {"mode": "MPOL_BIND", "nodes": "1"}
+		| .linux.resources.cpu.mems = "0"'
+
+	runc run test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"nodes are not a subset of the cpuset mems (0)"* ]]
+}
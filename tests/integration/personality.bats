@@ -0,0 +1,43 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc run personality for i686" {
+	requires arch_x86_64
+
+	update_config '.linux.personality = {"domain": "LINUX32"}
+		| .process.args = ["uname", "-m"]'
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"i686"* ]]
+}
+
+@test "runc exec personality for i686" {
+	requires arch_x86_64
+
+	update_config '.linux.personality = {"domain": "LINUX32"}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc exec test_busybox uname -m
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"i686"* ]]
+}
+
+@test "runc run personality with invalid domain" {
+	update_config '.linux.personality = {"domain": "LINUX64"}'
+
+	runc run test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"personality domain \"LINUX64\" is not supported"* ]]
+}
@@ -23,8 +23,46 @@ function teardown() {
 
 	runc ps test_busybox
 	[ "$status" -eq 0 ]
-	[[ ${lines[0]} =~ UID\ +PID\ +PPID\ +C\ +STIME\ +TTY\ +TIME\ +CMD+ ]]
-	[[ "${lines[1]}" == *"$(id -un 2>/dev/null)"*[0-9]* ]]
+	[[ ${lines[0]} =~ PID\ +PPID\ +NSPID\ +USER\ +STATE\ +RSS\ +TIME\ +CGROUP\ +CMD ]]
+	[[ "${lines[1]}" =~ [0-9]+\ +[0-9]+\ +1\ +"$(id -un 2>/dev/null)"\ .*\ /\ +sh ]]
+}
+
+@test "ps -f json-detailed" {
+	# ps is not supported, it requires cgroups
+	requires root
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	testcontainer test_busybox running
+
+	runc exec -d test_busybox sleep 100
+	[ "$status" -eq 0 ]
+
+	runc ps -f json-detailed test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$(echo "$output" | jq length)" == "2" ]]
+	[[ "$(echo "$output" | jq -r '.[0].ns_pid')" == "1" ]]
+	[[ "$(echo "$output" | jq -r '.[0].cgroup')" == "/" ]]
+	[[ "$(echo "$output" | jq -r '.[1].cmdline | join(" ")')" == "sleep 100" ]]
+}
+
+@test "ps --tree" {
+	# ps is not supported, it requires cgroups
+	requires root
+
+	update_config '.process.args = ["sh", "-c", "sleep 100 & wait"]'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	testcontainer test_busybox running
+
+	runc ps --tree test_busybox
+	[ "$status" -eq 0 ]
+	[[ "${lines[2]}" == *" \_ sleep 100" ]]
+
+	runc ps -f json-detailed --tree test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$(echo "$output" | jq length)" == "1" ]]
+	[[ "$(echo "$output" | jq -r '.[0].children[0].cmdline | join(" ")')" == "sleep 100" ]]
 }
 
 @test "ps -f json" {
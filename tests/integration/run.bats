@@ -57,3 +57,74 @@ function teardown() {
 	runc state test_run_keep
 	[ "$status" -ne 0 ]
 }
+
+@test "runc run --init [exit code]" {
+	update_config '.process.args = ["sh", "-c", "exit 42"]'
+
+	runc run --init test_init
+	[ "$status" -eq 42 ]
+}
+
+@test "runc run --init [reaps zombies]" {
+	update_config '.process.args = ["sleep", "100"]'
+
+	runc run -d --init --console-socket "$CONSOLE_SOCKET" test_init
+	[ "$status" -eq 0 ]
+	testcontainer test_init running
+
+	# The container process is a child of runc init, which stays as pid 1.
+	runc exec test_init cat /proc/1/comm
+	[ "$status" -eq 0 ]
+	[[ "$output" != "sleep" ]]
+
+	# Orphan a process, which exits right away.
+	runc exec test_init sh -c '(true &)'
+	[ "$status" -eq 0 ]
+	sleep 1
+	runc exec test_init sh -c 'grep -l "^State:.*Z" /proc/[0-9]*/status'
+	[ "$status" -ne 0 ]
+	[ -z "$output" ]
+
+	# The signals sent to the container are forwarded to sleep.
+	runc kill test_init TERM
+	[ "$status" -eq 0 ]
+	wait_for_container 10 1 test_init stopped
+}
+
+@test "runc run --init [security labels]" {
+	requires root
+
+	# The init is confined like the container process.
+	if grep -qs '^docker-default ' /sys/kernel/security/apparmor/profiles; then
+		update_config '.process.apparmorProfile = "docker-default"'
+	elif selinuxenabled 2>/dev/null && seinfo -t container_t >/dev/null 2>&1; then
+		update_config '.process.selinuxLabel = "system_u:system_r:container_t:s0:c1,c2"'
+	else
+		skip "requires docker-default AppArmor profile or container_t SELinux type"
+	fi
+	update_config '.process.args = ["sh", "-c", "for p in 1 self; do tr -d \"\\0\\n\" </proc/$p/attr/current; echo; done"]'
+
+	runc run --init test_init
+	[ "$status" -eq 0 ]
+	[ "${lines[0]}" = "${lines[1]}" ]
+	[[ "${lines[0]}" != *unconfined* ]]
+}
+
+@test "runc run [domainname]" {
+	update_config '.domainname = "example.org"
+		| .process.args = ["cat", "/proc/sys/kernel/domainname"]'
+
+	runc run test_domainname
+	[ "$status" -eq 0 ]
+	[[ "$output" == "example.org"* ]]
+}
+
+@test "runc run [domainname without uts namespace]" {
+	update_config '.domainname = "example.org"
+		| del(.hostname)
+		| .linux.namespaces -= [{"type": "uts"}]'
+
+	runc run test_domainname
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"unable to set domainname without a private UTS namespace"* ]]
+}
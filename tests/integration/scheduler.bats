@@ -0,0 +1,54 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc run with scheduler" {
+	update_config '.process.scheduler = {"policy": "SCHED_BATCH", "nice": 7, "flags": ["SCHED_FLAG_RESET_ON_FORK"]}
+		| .process.args = ["awk", "/^(policy|prio) / { print $3 }", "/proc/self/sched"]'
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	# SCHED_BATCH is 3, and the priority is 120 + nice.
+	[ "${lines[0]}" = "3" ]
+	[ "${lines[1]}" = "127" ]
+}
+
+@test "runc exec with scheduler" {
+	update_config '.process.scheduler = {"policy": "SCHED_BATCH", "nice": 3}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	# The exec'd processes get the scheduler of the container process...
+	runc exec test_busybox awk '/^policy / { print $3 }' /proc/self/sched
+	[ "$status" -eq 0 ]
+	[ "$output" = "3" ]
+
+	# ... unless they have their own.
+	proc='
+{
+	"args": ["awk", "/^policy / { print $3 }", "/proc/self/sched"],
+	"cwd": "/",
+	"scheduler": {"policy": "SCHED_IDLE"}
+}'
+	runc exec -p <(echo "$proc") test_busybox
+	[ "$status" -eq 0 ]
+	# SCHED_IDLE is 5.
+	[ "$output" = "5" ]
+}
+
+@test "runc run with invalid scheduler" {
+	update_config '.process.scheduler = {"policy": "SCHED_OTHER", "priority": 10}'
+
+	runc run test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"scheduler priority can only be set with the SCHED_FIFO or SCHED_RR policy"* ]]
+}
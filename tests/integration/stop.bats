@@ -0,0 +1,59 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc stop" {
+	update_config '.process.args = ["sh", "-c", "trap \"exit 0\" TERM; while true; do sleep 0.1; done"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_stop
+	[ "$status" -eq 0 ]
+	testcontainer test_stop running
+
+	runc stop --timeout 10 test_stop
+	[ "$status" -eq 0 ]
+	[[ "$output" == "signaled" ]]
+	testcontainer test_stop stopped
+
+	runc stop test_stop
+	[ "$status" -eq 0 ]
+	[[ "$output" == "not running" ]]
+}
+
+@test "runc stop [escalate to SIGKILL]" {
+	# As pid 1, sh ignores SIGTERM.
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_stop
+	[ "$status" -eq 0 ]
+	testcontainer test_stop running
+
+	runc stop --timeout 1 test_stop
+	[ "$status" -eq 0 ]
+	[[ "$output" == "killed" ]]
+	testcontainer test_stop stopped
+}
+
+@test "runc stop [paused]" {
+	requires cgroups_freezer
+	if [ $EUID -ne 0 ]; then
+		requires rootless_cgroup
+		set_cgroups_path
+	fi
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_stop
+	[ "$status" -eq 0 ]
+
+	runc pause test_stop
+	[ "$status" -eq 0 ]
+
+	runc stop --signal KILL test_stop
+	[ "$status" -eq 0 ]
+	[[ "$output" == "signaled" ]]
+	testcontainer test_stop stopped
+}
@@ -0,0 +1,58 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc run [timens offsets]" {
+	requires timens
+
+	update_config '.linux.namespaces += [{"type": "time"}]
+		| .linux.timeOffsets = {
+			"monotonic": { "secs": 7881, "nanosecs": 2718281 },
+			"boottime": { "secs": 1337, "nanosecs": 3141519 }
+		}
+		| .process.args = ["cat", "/proc/self/timens_offsets"]'
+
+	runc run test_busybox
+	[ "$status" -eq 0 ]
+	grep -E '^monotonic\s+7881\s+2718281$' <<<"$output"
+	grep -E '^boottime\s+1337\s+3141519$' <<<"$output"
+}
+
+@test "runc exec [timens offsets]" {
+	requires timens
+
+	update_config '.linux.namespaces += [{"type": "time"}]
+		| .linux.timeOffsets = {
+			"boottime": { "secs": 864000 }
+		}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc exec test_busybox cat /proc/self/timens_offsets
+	[ "$status" -eq 0 ]
+	grep -E '^boottime\s+864000\s+0$' <<<"$output"
+
+	# The uptime includes the boottime offset.
+	runc exec test_busybox cat /proc/uptime
+	[ "$status" -eq 0 ]
+	[ "${output%%.*}" -ge 864000 ]
+}
+
+@test "runc run [timens offsets without time namespace]" {
+	requires timens
+
+	update_config '.linux.timeOffsets = {"boottime": { "secs": 1337 }}'
+
+	runc run test_busybox
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"time namespace isn't enabled"* ]]
+}
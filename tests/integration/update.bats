@@ -766,3 +766,142 @@ EOF
 	runc update test_update --memory 1024
 	testcontainer test_update stopped
 }
+
+@test "update memory high, low and min" {
+	requires cgroups_v2
+	[ $EUID -ne 0 ] && requires rootless_cgroup
+
+	update_config '.linux.resources.unified |= {"memory.high": "max"}'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_update
+	[ "$status" -eq 0 ]
+	check_cgroup_value "memory.high" "max"
+	# memory.low is set from the reservation.
+	check_cgroup_value "memory.low" 25165824
+
+	runc update test_update --memory-min 8M --memory-low 16M --memory-high 30M
+	[ "$status" -eq 0 ]
+	check_cgroup_value "memory.min" 8388608
+	check_systemd_value "MemoryMin" 8388608
+	check_cgroup_value "memory.low" 16777216
+	check_systemd_value "MemoryLow" 16777216
+	check_cgroup_value "memory.high" 31457280
+	check_systemd_value "MemoryHigh" 31457280
+
+	# The values not given are kept.
+	runc update test_update --memory-high -1
+	[ "$status" -eq 0 ]
+	check_cgroup_value "memory.high" "max"
+	check_cgroup_value "memory.low" 16777216
+
+	# Zero values remove the protection.
+	runc update test_update --memory-min 0 --memory-low 0
+	[ "$status" -eq 0 ]
+	check_cgroup_value "memory.min" 0
+	check_systemd_value "MemoryMin" 0
+	check_cgroup_value "memory.low" 0
+	check_systemd_value "MemoryLow" 0
+	check_cgroup_value "memory.high" "max"
+
+	# memory.high above memory.max is rejected.
+	runc update test_update --memory 32M --memory-high 64M
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"memory.high (67108864) must not be greater than memory.max (33554432)"* ]]
+	check_cgroup_value "memory.high" "max"
+}
+
+@test "update memory low on cgroup v1" {
+	requires cgroups_v1 cgroups_memory
+	[ $EUID -ne 0 ] && requires rootless_cgroup
+
+	# The unified memory.low is accepted on cgroup v1, and the reservation
+	# takes precedence for the soft limit.
+	update_config '.linux.resources.unified |= {"memory.low": "16777216"}'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_update
+	[ "$status" -eq 0 ]
+	check_cgroup_value "memory.soft_limit_in_bytes" 25165824
+
+	runc update test_update --memory-reservation 0 --memory-low 8M
+	[ "$status" -eq 0 ]
+	check_cgroup_value "memory.soft_limit_in_bytes" 8388608
+
+	# A zero memory.low removes the soft limit.
+	runc update test_update --memory-low 0
+	[ "$status" -eq 0 ]
+	check_cgroup_value "memory.soft_limit_in_bytes" "$(cat "${CGROUP_MEMORY_BASE_PATH}/memory.soft_limit_in_bytes")"
+}
+
+@test "update per-device io limits" {
+	requires root # to create a loop device
+
+	dd if=/dev/zero of=backing.img bs=4096 count=1
+	dev=$(losetup --find --show backing.img) || skip "unable to create a loop device"
+	IFS=$' \t:' read -r major minor <<<"$(lsblk -nd -o MAJ:MIN "$dev")"
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_update
+	[ "$status" -eq 0 ]
+
+	runc update test_update --io-max "$major:$minor rbps=1048576 wiops=100"
+	[ "$status" -eq 0 ]
+	if [ -v CGROUP_V2 ]; then
+		check_cgroup_value io.max "$major:$minor rbps=1048576 wbps=max riops=max wiops=100"
+	else
+		check_cgroup_value blkio.throttle.read_bps_device "$major:$minor 1048576"
+		check_cgroup_value blkio.throttle.write_iops_device "$major:$minor 100"
+	fi
+
+	# The other limits of the device are kept.
+	runc update test_update --io-max "$major:$minor rbps=max"
+	[ "$status" -eq 0 ]
+	if [ -v CGROUP_V2 ]; then
+		check_cgroup_value io.max "$major:$minor rbps=max wbps=max riops=max wiops=100"
+	else
+		check_cgroup_value blkio.throttle.read_bps_device ""
+		check_cgroup_value blkio.throttle.write_iops_device "$major:$minor 100"
+	fi
+
+	if [ -v CGROUP_V2 ] && [ -e "$CGROUP_PATH/io.latency" ]; then
+		runc update -r - test_update <<EOF
+{
+  "unified": {
+    "io.latency": "$major:$minor target=25000"
+  }
+}
+EOF
+		[ "$status" -eq 0 ]
+		check_cgroup_value io.latency "$major:$minor target=25000"
+	fi
+
+	# Per-device weights use BFQ if available, or else io.weight (cgroup v2
+	# only), which needs io.cost enabled for the device, so the weight must
+	# either be set or the update must fail.
+	if grep -qw bfq "/sys/block/${dev#/dev/}/queue/scheduler" &&
+		echo bfq >"/sys/block/${dev#/dev/}/queue/scheduler"; then
+		runc update test_update --io-weight-device "$major:$minor 444"
+		[ "$status" -eq 0 ]
+		if [ -v CGROUP_V2 ]; then
+			file="io.bfq.weight"
+		else
+			file="blkio.bfq.weight_device"
+		fi
+		weights=$(get_cgroup_value $file)
+		[[ "$weights" == *"$major:$minor 444"* ]]
+	elif [ -v CGROUP_V2 ]; then
+		runc update test_update --io-weight-device "$major:$minor 444"
+		if [ "$status" -eq 0 ]; then
+			weights=$(get_cgroup_value io.weight)
+			[[ "$weights" == *"$major:$minor 4384"* ]]
+		else
+			[[ "$output" == *"setting device weight \"$major:$minor 444\""* ]]
+		fi
+	fi
+
+	losetup -d "$dev"
+
+	runc update test_update --io-max "0:0 rbps=1"
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"0:0 is not a block device"* ]]
+
+	runc update test_update --io-max "$major:$minor rbps=0"
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"invalid limit"* ]]
+}
@@ -0,0 +1,64 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc wait [exit code]" {
+	update_config '.process.args = ["sh", "-c", "sleep 1; exit 3"]'
+
+	runc create --monitor --console-socket "$CONSOLE_SOCKET" test_wait
+	[ "$status" -eq 0 ]
+	testcontainer test_wait created
+
+	runc start test_wait
+	[ "$status" -eq 0 ]
+
+	runc wait test_wait
+	[ "$status" -eq 3 ]
+
+	runc state test_wait
+	[ "$status" -eq 0 ]
+	[[ "$(echo "$output" | jq .exitStatus.exit_code)" == "3" ]]
+}
+
+@test "runc wait [killed]" {
+	runc run -d --monitor --console-socket "$CONSOLE_SOCKET" test_wait
+	[ "$status" -eq 0 ]
+	testcontainer test_wait running
+
+	runc kill test_wait KILL
+	[ "$status" -eq 0 ]
+
+	runc wait test_wait
+	[ "$status" -eq 137 ]
+
+	runc state test_wait
+	[ "$status" -eq 0 ]
+	[[ "$(echo "$output" | jq .exitStatus.signal)" == "9" ]]
+}
+
+@test "runc wait [without monitor]" {
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_wait
+	[ "$status" -eq 0 ]
+
+	runc kill test_wait KILL
+	[ "$status" -eq 0 ]
+	wait_for_container 10 1 test_wait stopped
+
+	runc wait test_wait
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"exit status is not available"* ]]
+}
+
+@test "runc run --monitor requires --detach" {
+	runc run --monitor test_wait
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"require --detach"* ]]
+}
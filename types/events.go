@@ -1,12 +1,46 @@
 package types
 
-import "github.com/opencontainers/runc/libcontainer/intelrdt"
+import (
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/intelrdt"
+)
 
 // Event struct for encoding the event data to json.
 type Event struct {
-	Type string      `json:"type"`
-	ID   string      `json:"id"`
-	Data interface{} `json:"data,omitempty"`
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	// Seq is the sequence number of a lifecycle event, which can be used
+	// to resume receiving the events after it. It is not set for the
+	// other events.
+	Seq       uint64      `json:"seq,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// Lifecycle is the data of the lifecycle events (created, started, paused,
+// resumed, exec-started, exec-exited, stopped and deleted).
+type Lifecycle struct {
+	// Pid is the pid of the container init process, or of the exec'd
+	// process for the exec-started and exec-exited events.
+	Pid int `json:"pid,omitempty"`
+	// ExitCode is set for the exec-exited event, and for the stopped event
+	// if the exit code is known.
+	ExitCode  *int `json:"exit_code,omitempty"`
+	Signal    int  `json:"signal,omitempty"`
+	OOMKilled bool `json:"oom_killed,omitempty"`
+}
+
+// Pressure is the data of the pressure events, which are sent every time a
+// PSI trigger fires.
+type Pressure struct {
+	// Resource is "cpu", "memory" or "io".
+	Resource string `json:"resource"`
+	// Kind is "some" or "full".
+	Kind string `json:"kind"`
+	// Units: microseconds.
+	Threshold uint64 `json:"threshold"`
+	Window    uint64 `json:"window"`
 }
 
 // stats is the runc specific stats structure for stability when encoding and decoding stats.
@@ -43,6 +77,7 @@ type Blkio struct {
 	IoMergedRecursive       []BlkioEntry `json:"ioMergedRecursive,omitempty"`
 	IoTimeRecursive         []BlkioEntry `json:"ioTimeRecursive,omitempty"`
 	SectorsRecursive        []BlkioEntry `json:"sectorsRecursive,omitempty"`
+	PSI                     *PSIStats    `json:"psi,omitempty"`
 }
 
 type Pids struct {
@@ -66,9 +101,24 @@ type CpuUsage struct {
 	User         uint64   `json:"user"`
 }
 
+type PSIData struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	// Units: microseconds.
+	Total uint64 `json:"total"`
+}
+
+// PSIStats are the pressure stall information of a resource.
+type PSIStats struct {
+	Some PSIData `json:"some,omitempty"`
+	Full PSIData `json:"full,omitempty"`
+}
+
 type Cpu struct {
 	Usage      CpuUsage   `json:"usage,omitempty"`
 	Throttling Throttling `json:"throttling,omitempty"`
+	PSI        *PSIStats  `json:"psi,omitempty"`
 }
 
 type CPUSet struct {
@@ -99,6 +149,22 @@ type Memory struct {
 	Kernel    MemoryEntry       `json:"kernel,omitempty"`
 	KernelTCP MemoryEntry       `json:"kernelTCP,omitempty"`
 	Raw       map[string]uint64 `json:"raw,omitempty"`
+	PSI       *PSIStats         `json:"psi,omitempty"`
+	Events    *MemoryEvents     `json:"events,omitempty"`
+	High      uint64            `json:"high,omitempty"`
+	Low       uint64            `json:"low,omitempty"`
+	Min       uint64            `json:"min,omitempty"`
+}
+
+// MemoryEvents are the memory event counters (cgroup v2 only). They are also
+// the data of the memory.high and memory.max events.
+type MemoryEvents struct {
+	Low          uint64 `json:"low"`
+	High         uint64 `json:"high"`
+	Max          uint64 `json:"max"`
+	OOM          uint64 `json:"oom"`
+	OOMKill      uint64 `json:"oom_kill"`
+	OOMGroupKill uint64 `json:"oom_group_kill"`
 }
 
 type L3CacheInfo struct {
@@ -37,10 +37,13 @@ type Linux struct {
 	// Nil value means "unknown", not "no support for any capability".
 	Capabilities []string `json:"capabilities,omitempty"`
 
-	Cgroup   *Cgroup   `json:"cgroup,omitempty"`
-	Seccomp  *Seccomp  `json:"seccomp,omitempty"`
-	Apparmor *Apparmor `json:"apparmor,omitempty"`
-	Selinux  *Selinux  `json:"selinux,omitempty"`
+	Cgroup      *Cgroup      `json:"cgroup,omitempty"`
+	Seccomp     *Seccomp     `json:"seccomp,omitempty"`
+	Apparmor    *Apparmor    `json:"apparmor,omitempty"`
+	Selinux     *Selinux     `json:"selinux,omitempty"`
+	Personality *Personality `json:"personality,omitempty"`
+
+	MountExtensions *MountExtensions `json:"mountExtensions,omitempty"`
 }
 
 // Seccomp represents the "seccomp" field.
@@ -62,6 +65,26 @@ type Seccomp struct {
 	Archs []string `json:"archs,omitempty"`
 }
 
+// MountExtensions represents the "mountExtensions" field.
+type MountExtensions struct {
+	// IDMap represents the status of idmap mounts support.
+	IDMap *IDMap `json:"idmap,omitempty"`
+}
+
+// IDMap represents the "idmap" field.
+type IDMap struct {
+	// Enabled is true if idmap mounts support is compiled in.
+	// Nil value means "unknown", not "false".
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// Personality represents the "personality" field.
+type Personality struct {
+	// Domains is the list of the recognized personality domains, e.g., "LINUX32".
+	// Nil value means "unknown", not "no support for any domain".
+	Domains []string `json:"domains,omitempty"`
+}
+
 // Apparmor represents the "apparmor" field.
 type Apparmor struct {
 	// Enabled is true if AppArmor support is compiled in.
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/sirupsen/logrus"
@@ -13,6 +14,7 @@ import (
 	"github.com/docker/go-units"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/intelrdt"
+	"github.com/opencontainers/runc/libcontainer/specconv"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/urfave/cli"
 )
@@ -64,6 +66,14 @@ other options are ignored.
 			Name:  "blkio-weight",
 			Usage: "Specifies per cgroup weight, range is from 10 to 1000",
 		},
+		cli.StringSliceFlag{
+			Name:  "io-max",
+			Usage: "Per-device IO limits, as '<major>:<minor> [rbps=<bytes>] [wbps=<bytes>] [riops=<ios>] [wiops=<ios>]' ('max' for no limit), can be repeated",
+		},
+		cli.StringSliceFlag{
+			Name:  "io-weight-device",
+			Usage: "Per-device IO weight, as '<major>:<minor> <weight>', can be repeated",
+		},
 		cli.StringFlag{
 			Name:  "cpu-period",
 			Usage: "CPU CFS period to be used for hardcapping (in usecs). 0 to use system default",
@@ -114,6 +124,18 @@ other options are ignored.
 			Name:  "memory-swap",
 			Usage: "Total memory usage (memory + swap); set '-1' to enable unlimited swap",
 		},
+		cli.StringFlag{
+			Name:  "memory-high",
+			Usage: "Memory usage throttle limit (in bytes); set '-1' for no limit",
+		},
+		cli.StringFlag{
+			Name:  "memory-low",
+			Usage: "Best-effort memory protection (in bytes)",
+		},
+		cli.StringFlag{
+			Name:  "memory-min",
+			Usage: "Hard memory protection (in bytes)",
+		},
 		cli.IntFlag{
 			Name:  "pids-limit",
 			Usage: "Maximum number of pids allowed in the container",
@@ -158,11 +180,14 @@ other options are ignored.
 				Weight: u16Ptr(0),
 			},
 			Pids: &specs.LinuxPids{
-				Limit: 0,
+				Limit: i64Ptr(0),
 			},
 		}
 
 		config := container.Config()
+		// The memory boundaries the spec has no fields for. Unlike the
+		// other values, they are only updated if given (nil if not).
+		var memHigh, memLow, memMin *int64
 
 		if in := context.String("resources"); in != "" {
 			var (
@@ -186,6 +211,18 @@ other options are ignored.
 			if val := context.Int("blkio-weight"); val != 0 {
 				r.BlockIO.Weight = u16Ptr(uint16(val))
 			}
+			for _, val := range context.StringSlice("io-max") {
+				if err := parseIOMax(val, r.BlockIO); err != nil {
+					return fmt.Errorf("invalid value for io-max: %w", err)
+				}
+			}
+			for _, val := range context.StringSlice("io-weight-device") {
+				wd, err := parseWeightDevice(val)
+				if err != nil {
+					return fmt.Errorf("invalid value for io-weight-device: %w", err)
+				}
+				r.BlockIO.WeightDevice = append(r.BlockIO.WeightDevice, wd)
+			}
 			if val := context.String("cpuset-cpus"); val != "" {
 				r.CPU.Cpus = val
 			}
@@ -248,8 +285,32 @@ other options are ignored.
 					*pair.dest = v
 				}
 			}
+			for _, pair := range []struct {
+				opt  string
+				dest **int64
+			}{
+				{"memory-high", &memHigh},
+				{"memory-low", &memLow},
+				{"memory-min", &memMin},
+			} {
+				if !context.IsSet(pair.opt) {
+					continue
+				}
+				v := int64(-1)
+				if val := context.String(pair.opt); val != "-1" {
+					v, err = units.RAMInBytes(val)
+					if err != nil {
+						return fmt.Errorf("invalid value for %s: %w", pair.opt, err)
+					}
+				}
+				// 0 removes the limit or protection.
+				if v == 0 && pair.opt == "memory-high" {
+					v = -1
+				}
+				*pair.dest = &v
+			}
 
-			r.Pids.Limit = int64(context.Int("pids-limit"))
+			*r.Pids.Limit = int64(context.Int("pids-limit"))
 		}
 
 		if *r.Memory.Kernel != 0 || *r.Memory.KernelTCP != 0 {
@@ -258,6 +319,9 @@ other options are ignored.
 
 		// Update the values
 		config.Cgroups.Resources.BlkioWeight = *r.BlockIO.Weight
+		if err := updateBlkioDevices(config.Cgroups.Resources, r.BlockIO); err != nil {
+			return err
+		}
 
 		// Setting CPU quota and period independently does not make much sense,
 		// but historically runc allowed it and this needs to be supported
@@ -297,8 +361,38 @@ other options are ignored.
 		config.Cgroups.Resources.MemoryReservation = *r.Memory.Reservation
 		config.Cgroups.Resources.MemorySwap = *r.Memory.Swap
 		config.Cgroups.Resources.MemoryCheckBeforeUpdate = *r.Memory.CheckBeforeUpdate
-		config.Cgroups.Resources.PidsLimit = r.Pids.Limit
+		config.Cgroups.Resources.PidsLimit = *r.Pids.Limit
 		config.Cgroups.Resources.Unified = r.Unified
+		_, setLatency := r.Unified["io.latency"]
+		for _, pair := range []struct {
+			value *int64
+			dest  **int64
+		}{
+			{memHigh, &config.Cgroups.Resources.MemoryHigh},
+			{memLow, &config.Cgroups.Resources.MemoryLow},
+			{memMin, &config.Cgroups.Resources.MemoryMin},
+		} {
+			if pair.value != nil {
+				*pair.dest = pair.value
+			}
+		}
+		if err := specconv.ConvertUnified(config.Cgroups.Resources); err != nil {
+			return err
+		}
+		res := config.Cgroups.Resources
+		if err := cgroups.CheckMemoryBoundaries(res.MemoryMin, res.MemoryLow, res.MemoryHigh, res.Memory); err != nil {
+			return err
+		}
+		if setLatency {
+			if !cgroups.IsCgroup2UnifiedMode() {
+				return errors.New("io latency targets require cgroup v2")
+			}
+			for _, ld := range res.IOLatencyDevice {
+				if err := cgroups.CheckBlockDevice(ld.Major, ld.Minor); err != nil {
+					return err
+				}
+			}
+		}
 
 		// Update Intel RDT
 		l3CacheSchema := context.String("l3-cache-schema")
@@ -341,3 +435,124 @@ other options are ignored.
 		return container.Set(config)
 	},
 }
+
+// parseBlockDevice parses a "<major>:<minor>" block device.
+func parseBlockDevice(s string) (specs.LinuxBlockIODevice, error) {
+	var dev specs.LinuxBlockIODevice
+	if n, err := fmt.Sscanf(s, "%d:%d", &dev.Major, &dev.Minor); err != nil || n != 2 {
+		return dev, fmt.Errorf("invalid block device %q, expected <major>:<minor>", s)
+	}
+	return dev, nil
+}
+
+// parseIOMax parses the per-device IO limits in the io.max format, and adds
+// them to the throttle devices of b.
+func parseIOMax(s string, b *specs.LinuxBlockIO) error {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return fmt.Errorf("%q: expected a block device and at least one limit", s)
+	}
+	dev, err := parseBlockDevice(fields[0])
+	if err != nil {
+		return err
+	}
+	for _, f := range fields[1:] {
+		key, val, _ := strings.Cut(f, "=")
+		var rate uint64 // 0 is no limit
+		if val != "max" {
+			rate, err = strconv.ParseUint(val, 10, 64)
+			if err != nil || rate == 0 {
+				return fmt.Errorf("invalid limit %q", f)
+			}
+		}
+		td := specs.LinuxThrottleDevice{LinuxBlockIODevice: dev, Rate: rate}
+		switch key {
+		case "rbps":
+			b.ThrottleReadBpsDevice = append(b.ThrottleReadBpsDevice, td)
+		case "wbps":
+			b.ThrottleWriteBpsDevice = append(b.ThrottleWriteBpsDevice, td)
+		case "riops":
+			b.ThrottleReadIOPSDevice = append(b.ThrottleReadIOPSDevice, td)
+		case "wiops":
+			b.ThrottleWriteIOPSDevice = append(b.ThrottleWriteIOPSDevice, td)
+		default:
+			return fmt.Errorf("unknown limit %q", f)
+		}
+	}
+	return nil
+}
+
+// parseWeightDevice parses a "<major>:<minor> <weight>" device weight.
+func parseWeightDevice(s string) (specs.LinuxWeightDevice, error) {
+	var wd specs.LinuxWeightDevice
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return wd, fmt.Errorf("%q: expected a block device and a weight", s)
+	}
+	dev, err := parseBlockDevice(fields[0])
+	if err != nil {
+		return wd, err
+	}
+	weight, err := strconv.ParseUint(fields[1], 10, 16)
+	if err != nil {
+		return wd, fmt.Errorf("invalid weight %q", fields[1])
+	}
+	wd.LinuxBlockIODevice = dev
+	wd.Weight = u16Ptr(uint16(weight))
+	return wd, nil
+}
+
+// updateBlkioDevices sets the per-device weights and limits of b in res,
+// replacing the values of the devices which are already there.
+func updateBlkioDevices(res *configs.Resources, b *specs.LinuxBlockIO) error {
+	for _, wd := range b.WeightDevice {
+		if err := cgroups.CheckBlockDevice(wd.Major, wd.Minor); err != nil {
+			return err
+		}
+		var dev *configs.WeightDevice
+		for _, d := range res.BlkioWeightDevice {
+			if d.Major == wd.Major && d.Minor == wd.Minor {
+				dev = d
+				break
+			}
+		}
+		if dev == nil {
+			dev = configs.NewWeightDevice(wd.Major, wd.Minor, 0, 0)
+			res.BlkioWeightDevice = append(res.BlkioWeightDevice, dev)
+		}
+		if wd.Weight != nil {
+			dev.Weight = *wd.Weight
+		}
+		if wd.LeafWeight != nil {
+			dev.LeafWeight = *wd.LeafWeight
+		}
+	}
+	for _, pair := range []struct {
+		devs    *[]*configs.ThrottleDevice
+		updates []specs.LinuxThrottleDevice
+	}{
+		{&res.BlkioThrottleReadBpsDevice, b.ThrottleReadBpsDevice},
+		{&res.BlkioThrottleWriteBpsDevice, b.ThrottleWriteBpsDevice},
+		{&res.BlkioThrottleReadIOPSDevice, b.ThrottleReadIOPSDevice},
+		{&res.BlkioThrottleWriteIOPSDevice, b.ThrottleWriteIOPSDevice},
+	} {
+		for _, td := range pair.updates {
+			if err := cgroups.CheckBlockDevice(td.Major, td.Minor); err != nil {
+				return err
+			}
+			var dev *configs.ThrottleDevice
+			for _, d := range *pair.devs {
+				if d.Major == td.Major && d.Minor == td.Minor {
+					dev = d
+					break
+				}
+			}
+			if dev == nil {
+				*pair.devs = append(*pair.devs, configs.NewThrottleDevice(td.Major, td.Minor, td.Rate))
+			} else {
+				dev.Rate = td.Rate
+			}
+		}
+	}
+	return nil
+}
@@ -54,6 +54,15 @@ func newProcess(p specs.Process) (*libcontainer.Process, error) {
 		Label:           p.SelinuxLabel,
 		NoNewPrivileges: &p.NoNewPrivileges,
 		AppArmorProfile: p.ApparmorProfile,
+		Scheduler:       p.Scheduler,
+		IOPriority:      p.IOPriority,
+	}
+	if p.ExecCPUAffinity != nil {
+		aff, err := configs.ConvertCPUAffinity(p.ExecCPUAffinity)
+		if err != nil {
+			return nil, err
+		}
+		lp.CPUAffinity = aff
 	}
 
 	if p.ConsoleSize != nil {
@@ -172,6 +181,7 @@ func createContainer(context *cli.Context, id string, spec *specs.Spec) (*libcon
 		UseSystemdCgroup: context.GlobalBool("systemd-cgroup"),
 		NoPivotRoot:      context.Bool("no-pivot"),
 		NoNewKeyring:     context.Bool("no-new-keyring"),
+		Init:             context.Bool("init"),
 		Spec:             spec,
 		RootlessEUID:     os.Geteuid() != 0,
 		RootlessCgroups:  rootlessCg,
@@ -185,19 +195,22 @@ func createContainer(context *cli.Context, id string, spec *specs.Spec) (*libcon
 }
 
 type runner struct {
-	init            bool
-	enableSubreaper bool
-	shouldDestroy   bool
-	detach          bool
-	listenFDs       []*os.File
-	preserveFDs     int
-	pidFile         string
-	consoleSocket   string
-	container       *libcontainer.Container
-	action          CtAct
-	notifySocket    *notifySocket
-	criuOpts        *libcontainer.CriuOpts
-	subCgroupPaths  map[string]string
+	init             bool
+	enableSubreaper  bool
+	shouldDestroy    bool
+	detach           bool
+	listenFDs        []*os.File
+	preserveFDs      int
+	pidFile          string
+	consoleSocket    string
+	container        *libcontainer.Container
+	action           CtAct
+	notifySocket     *notifySocket
+	criuOpts         *libcontainer.CriuOpts
+	subCgroupPaths   map[string]string
+	containerMonitor *containerMonitor
+	stdioLog         *stdioLog
+	attachRelay      *attachRelay
 }
 
 func (r *runner) run(config *specs.Process) (int, error) {
@@ -243,7 +256,14 @@ func (r *runner) run(config *specs.Process) (int, error) {
 	// with detaching containers, and then we get a tty after the container has
 	// started.
 	handler := newSignalHandler(r.enableSubreaper, r.notifySocket)
-	tty, err := setupIO(process, rootuid, rootgid, config.Terminal, detach, r.consoleSocket)
+	var tty *tty
+	if r.attachRelay != nil {
+		tty, err = r.attachRelay.setupIO(process, rootuid, rootgid, config.Terminal, r.stdioLog)
+	} else if r.stdioLog != nil {
+		tty, err = setupStdioLog(process, rootuid, rootgid, r.stdioLog)
+	} else {
+		tty, err = setupIO(process, rootuid, rootgid, config.Terminal, detach, r.consoleSocket)
+	}
 	if err != nil {
 		return -1, err
 	}
@@ -273,17 +293,31 @@ func (r *runner) run(config *specs.Process) (int, error) {
 			return -1, err
 		}
 	}
-	status, err := handler.forward(process, tty, detach)
+	ws, err := handler.forward(process, tty, detach)
 	if err != nil {
 		r.terminate(process)
 	}
 	if detach {
+		if err == nil && r.containerMonitor != nil {
+			return r.monitor(handler, process, tty)
+		}
 		return 0, nil
 	}
 	if err == nil {
+		if r.init {
+			r.recordExit(ws)
+		} else if pid, perr := process.Pid(); perr == nil {
+			r.container.RecordExecExit(pid, ws)
+		}
 		r.destroy()
 	}
-	return status, err
+	return utils.ExitStatus(ws), err
+}
+
+func (r *runner) recordExit(ws unix.WaitStatus) {
+	if err := r.container.RecordExit(ws); err != nil {
+		logrus.Warnf("unable to record container exit status: %v", err)
+	}
 }
 
 func (r *runner) destroy() {
@@ -300,12 +334,18 @@ func (r *runner) terminate(p *libcontainer.Process) {
 func (r *runner) checkTerminal(config *specs.Process) error {
 	detach := r.detach || (r.action == CT_ACT_CREATE)
 	// Check command-line for sanity.
-	if detach && config.Terminal && r.consoleSocket == "" {
+	if detach && config.Terminal && r.consoleSocket == "" && r.attachRelay == nil {
 		return errors.New("cannot allocate tty if runc will detach without setting console socket")
 	}
+	if r.attachRelay != nil && r.consoleSocket != "" {
+		return errors.New("cannot use console socket for an attachable container")
+	}
 	if (!detach || !config.Terminal) && r.consoleSocket != "" {
 		return errors.New("cannot use console socket if runc will not detach or allocate tty")
 	}
+	if config.Terminal && r.stdioLog != nil {
+		return errors.New("cannot capture stdio of a container with a tty")
+	}
 	return nil
 }
 
@@ -325,6 +365,19 @@ func validateProcessSpec(spec *specs.Process) error {
 	if spec.SelinuxLabel != "" && !selinux.GetEnabled() {
 		return errors.New("selinux label is specified in config, but selinux is disabled or not supported")
 	}
+	if spec.Scheduler != nil {
+		if _, err := configs.ToSchedAttr(spec.Scheduler); err != nil {
+			return err
+		}
+	}
+	if spec.IOPriority != nil {
+		if _, err := configs.ToIOPrio(spec.IOPriority); err != nil {
+			return err
+		}
+	}
+	if _, err := configs.ConvertCPUAffinity(spec.ExecCPUAffinity); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -337,6 +390,22 @@ const (
 )
 
 func startContainer(context *cli.Context, action CtAct, criuOpts *libcontainer.CriuOpts) (int, error) {
+	monitor, err := newContainerMonitor(context)
+	if err != nil {
+		return -1, err
+	}
+	stdioLog, err := stdioLogOptions(context)
+	if err != nil {
+		return -1, err
+	}
+	// Capturing stdio and relaying it to attach clients need a process
+	// to read the container output.
+	if (context.Bool("monitor") || stdioLog != nil || context.Bool("attachable")) && monitor == nil {
+		if action == CT_ACT_RUN && !context.Bool("detach") {
+			return -1, errors.New("--monitor, --capture-stdio and --attachable require --detach")
+		}
+		return execMonitor(context)
+	}
 	if err := revisePidFile(context); err != nil {
 		return -1, err
 	}
@@ -371,6 +440,21 @@ func startContainer(context *cli.Context, action CtAct, criuOpts *libcontainer.C
 		}
 	}
 
+	if stdioLog != nil {
+		if err := stdioLog.open(filepath.Join(context.GlobalString("root"), id, stdioLogFilename)); err != nil {
+			return -1, err
+		}
+		defer stdioLog.Close()
+	}
+
+	var relay *attachRelay
+	if context.Bool("attachable") {
+		relay, err = newAttachRelay(filepath.Join(context.GlobalString("root"), id, attachSocketFilename))
+		if err != nil {
+			return -1, err
+		}
+	}
+
 	// Support on-demand socket activation by passing file descriptors into the container init process.
 	listenFDs := []*os.File{}
 	if os.Getenv("LISTEN_FDS") != "" {
@@ -378,18 +462,21 @@ func startContainer(context *cli.Context, action CtAct, criuOpts *libcontainer.C
 	}
 
 	r := &runner{
-		enableSubreaper: !context.Bool("no-subreaper"),
-		shouldDestroy:   !context.Bool("keep"),
-		container:       container,
-		listenFDs:       listenFDs,
-		notifySocket:    notifySocket,
-		consoleSocket:   context.String("console-socket"),
-		detach:          context.Bool("detach"),
-		pidFile:         context.String("pid-file"),
-		preserveFDs:     context.Int("preserve-fds"),
-		action:          action,
-		criuOpts:        criuOpts,
-		init:            true,
+		enableSubreaper:  !context.Bool("no-subreaper"),
+		shouldDestroy:    !context.Bool("keep"),
+		container:        container,
+		listenFDs:        listenFDs,
+		notifySocket:     notifySocket,
+		consoleSocket:    context.String("console-socket"),
+		detach:           context.Bool("detach"),
+		pidFile:          context.String("pid-file"),
+		preserveFDs:      context.Int("preserve-fds"),
+		action:           action,
+		criuOpts:         criuOpts,
+		init:             true,
+		containerMonitor: monitor,
+		stdioLog:         stdioLog,
+		attachRelay:      relay,
 	}
 	return r.run(spec.Process)
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/urfave/cli"
+	"golang.org/x/sys/unix"
+)
+
+var waitCommand = cli.Command{
+	Name:  "wait",
+	Usage: "wait for a container to stop and exit with its exit status",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is the name for the instance of the container.`,
+	Description: `The wait command blocks until the init process of the container exits, and
+then exits with the same exit status (128 plus the signal number if the
+process was killed by a signal).
+
+The exit status is only known to runc if it was recorded by the parent of the
+container's init process, which is the case for containers created with the
+--monitor option, or run in the foreground with the --keep option.`,
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		es, err := waitForExit(context, container)
+		if err != nil {
+			return err
+		}
+		os.Exit(es.ExitCode)
+		return nil // to satisfy the linter
+	},
+}
+
+// waitForExit waits until the container is stopped and returns the exit
+// status of its init process.
+func waitForExit(context *cli.Context, container *libcontainer.Container) (*libcontainer.ExitStatus, error) {
+	monitorPidFile := filepath.Join(context.GlobalString("root"), container.ID(), monitorPidFilename)
+	for {
+		// Check the monitor before the exit status, as the monitor exits
+		// right after recording it.
+		monitored := isMonitorRunning(monitorPidFile)
+		state, err := container.State()
+		if err != nil {
+			return nil, err
+		}
+		if state.ExitStatus != nil {
+			return state.ExitStatus, nil
+		}
+		status, err := container.Status()
+		if err != nil {
+			return nil, err
+		}
+		if status == libcontainer.Stopped && !monitored {
+			return nil, errors.New("container exit status is not available")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// isMonitorRunning reports whether the container monitor with the pid
+// recorded in pidFile is still running.
+func isMonitorRunning(pidFile string) bool {
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(string(data))
+	if err != nil {
+		return false
+	}
+	err = unix.Kill(pid, 0)
+	return err == nil || errors.Is(err, unix.EPERM)
+}